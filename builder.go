@@ -0,0 +1,354 @@
+package pdfpagedata
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Builder puts together a PageData, keeping track of the bookkeeping
+// that is easy to get wrong by hand: each new processing step or question
+// gets a fresh UUID, the next Sequence number, a UnixTime (nanoseconds),
+// and Previous and PreviousHash set to the UUID and hash of the step
+// before it, as found by SelectProcessByLast or SelectQuestionByLast, so
+// that VerifyChain can check nothing has been changed since. Marking
+// actions are chained the same way, on each question. Given a
+// Clock (WithHLC), new steps are stamped with it too.
+//
+//	pd := NewBuilder().
+//		Exam(ExamDetails{CourseCode: "ENGI12123", Diet: "2020-Summer"}).
+//		Page(15, 20).
+//		Process("split", ContactDetails{Name: "gradex"}).
+//		Build()
+type Builder struct {
+	pd      PageData
+	now     func() time.Time
+	newUUID func() string
+	hlc     *Clock
+}
+
+// NewBuilder starts a new record, with a new page UUID
+func NewBuilder() *Builder {
+
+	b := &Builder{
+		now:     time.Now,
+		newUUID: newUUID,
+	}
+
+	b.pd.Page.UUID = b.newUUID()
+
+	return b
+}
+
+// UpdateBuilder starts the next revision of an existing record. The
+// record is copied, so pd is not changed.
+func UpdateBuilder(pd PageData) *Builder {
+
+	b := &Builder{
+		pd:      copyPageData(pd),
+		now:     time.Now,
+		newUUID: newUUID,
+	}
+
+	b.pd.Revision++
+
+	return b
+}
+
+// newUUID is a random (version 4) UUID
+func newUUID() string {
+	return uuid.New().String()
+}
+
+// copyPageData makes a deep copy, including any unknown fields, that
+// shares no lists, maps or pointers with pd
+func copyPageData(pd PageData) PageData {
+
+	var out PageData
+
+	deepCopy(reflect.ValueOf(&out).Elem(), reflect.ValueOf(pd))
+
+	return out
+}
+
+// deepCopy copies src into dst, which must be settable and zero
+func deepCopy(dst, src reflect.Value) {
+
+	switch src.Kind() {
+
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		deepCopy(dst.Elem(), src.Elem())
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			value := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(value, iter.Value())
+			dst.SetMapIndex(iter.Key(), value)
+		}
+
+	case reflect.Struct:
+		// copies unexported fields too, e.g. a Mark's, which hold no references
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				dst.Field(i).Set(reflect.Zero(src.Field(i).Type()))
+				deepCopy(dst.Field(i), src.Field(i))
+			}
+		}
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// WithClock sets where the builder gets the time from, e.g. for tests
+func (b *Builder) WithClock(now func() time.Time) *Builder {
+	b.now = now
+	return b
+}
+
+// WithUUIDs sets where the builder gets new UUIDs from, e.g. for tests
+func (b *Builder) WithUUIDs(newUUID func() string) *Builder {
+	b.newUUID = newUUID
+	return b
+}
+
+// WithHLC stamps new steps with the clock, which is first moved past
+// everything already in the record
+func (b *Builder) WithHLC(clock *Clock) *Builder {
+	clock.Observe(b.pd)
+	b.hlc = clock
+	return b
+}
+
+// stamp is the next HLC timestamp, if the builder has a clock
+func (b *Builder) stamp() *HLC {
+	if b.hlc == nil {
+		return nil
+	}
+	return b.hlc.Stamp()
+}
+
+// Exam sets the exam, giving it a UUID if it doesn't have one
+func (b *Builder) Exam(ed ExamDetails) *Builder {
+
+	if ed.UUID == "" {
+		ed.UUID = b.newUUID()
+	}
+
+	b.pd.Exam = ed
+
+	return b
+}
+
+func (b *Builder) Author(ad AuthorDetails) *Builder {
+	b.pd.Author = ad
+	return b
+}
+
+// Page sets the page number, and the number of pages, keeping the UUID
+func (b *Builder) Page(number, of int) *Builder {
+	b.pd.Page.Number = number
+	b.pd.Page.Of = of
+	return b
+}
+
+func (b *Builder) Filename(filename string) *Builder {
+	b.pd.Page.Filename = filename
+	return b
+}
+
+func (b *Builder) Contact(cd ContactDetails) *Builder {
+	b.pd.Contact = cd
+	return b
+}
+
+func (b *Builder) Submission(sd SubmissionDetails) *Builder {
+	b.pd.Submission = sd
+	return b
+}
+
+func (b *Builder) PreparedFor(preparedFor string) *Builder {
+	b.pd.PreparedFor = preparedFor
+	return b
+}
+
+func (b *Builder) ToDo(todo string) *Builder {
+	b.pd.ToDo = todo
+	return b
+}
+
+func (b *Builder) Custom(key, value string) *Builder {
+	b.pd.Custom = append(b.pd.Custom, CustomDetails{Key: key, Value: value})
+	return b
+}
+
+// Process adds the next processing step. Parameters without a
+// sequence number are numbered in the order given.
+func (b *Builder) Process(name string, by ContactDetails, params ...ParameterDetails) *Builder {
+
+	step := ProcessingDetails{
+		UUID:     b.newUUID(),
+		UnixTime: b.now().UnixNano(),
+		Name:     name,
+		By:       by,
+		Sequence: 1,
+		HLC:      b.stamp(),
+	}
+
+	if last, err := SelectProcessByLast(b.pd); err == nil {
+		step.Previous = last.UUID
+		step.PreviousHash, _ = StepHash(last)
+	}
+
+	// the last step by HLC need not have the highest sequence
+	for _, seq := range StepSequences(b.pd.Processing) {
+		if seq >= step.Sequence {
+			step.Sequence = seq + 1
+		}
+	}
+
+	for i, param := range params {
+		if param.Sequence == 0 {
+			param.Sequence = i + 1
+		}
+		step.Parameters = append(step.Parameters, param)
+	}
+
+	b.pd.Processing = append(b.pd.Processing, step)
+
+	return b
+}
+
+// Question adds the next question, filling in the UUID if not given,
+// and the sequence, time and link to the previous question
+func (b *Builder) Question(q QuestionDetails) *Builder {
+
+	if q.UUID == "" {
+		q.UUID = b.newUUID()
+	}
+
+	q.UnixTime = b.now().UnixNano()
+	q.Sequence = 1
+	q.Previous = ""
+	q.PreviousHash = ""
+	q.HLC = b.stamp()
+
+	// SelectQuestionByLast sorts in place, so give it a copy
+	if last, err := SelectQuestionByLast(PageData{
+		Questions: append([]QuestionDetails(nil), b.pd.Questions...),
+	}); err == nil {
+		q.Previous = last.UUID
+		q.PreviousHash, _ = QuestionHash(last)
+	}
+
+	for _, other := range b.pd.Questions {
+		if other.Sequence >= q.Sequence {
+			q.Sequence = other.Sequence + 1
+		}
+	}
+
+	b.pd.Questions = append(b.pd.Questions, q)
+
+	return b
+}
+
+// Marking adds a marking action to the question (or part) with this
+// UUID, filling in the UUID if not given, and the time and link to the
+// question's previous action, of any kind. Nothing is added if there is
+// no such question.
+func (b *Builder) Marking(questionUUID string, a MarkingAction) *Builder {
+	return b.action(questionUUID, a, func(q *QuestionDetails) *[]MarkingAction { return &q.Marking })
+}
+
+// Moderating is Marking, for a moderating action
+func (b *Builder) Moderating(questionUUID string, a MarkingAction) *Builder {
+	return b.action(questionUUID, a, func(q *QuestionDetails) *[]MarkingAction { return &q.Moderating })
+}
+
+// Checking is Marking, for a checking action
+func (b *Builder) Checking(questionUUID string, a MarkingAction) *Builder {
+	return b.action(questionUUID, a, func(q *QuestionDetails) *[]MarkingAction { return &q.Checking })
+}
+
+func (b *Builder) action(questionUUID string, a MarkingAction, list func(*QuestionDetails) *[]MarkingAction) *Builder {
+
+	q := findQuestion(b.pd.Questions, questionUUID)
+	if q == nil {
+		return b
+	}
+
+	if a.UUID == "" {
+		a.UUID = b.newUUID()
+	}
+
+	a.UnixTime = b.now().UnixNano()
+	a.Previous = ""
+	a.PreviousHash = ""
+	a.HLC = b.stamp()
+
+	if last, ok := lastAction(*q); ok {
+		a.Previous = last.UUID
+		a.PreviousHash, _ = ActionHash(last)
+	}
+
+	actions := list(q)
+	*actions = append(*actions, a)
+
+	return b
+}
+
+func findQuestion(qs []QuestionDetails, uuid string) *QuestionDetails {
+
+	for i := range qs {
+		if qs[i].UUID == uuid {
+			return &qs[i]
+		}
+		if q := findQuestion(qs[i].Parts, uuid); q != nil {
+			return q
+		}
+	}
+
+	return nil
+}
+
+// lastAction is the question's latest marking action of any kind; of
+// those that tie, a checking action, then moderating, then marking
+func lastAction(q QuestionDetails) (MarkingAction, bool) {
+
+	var actions []MarkingAction
+	for _, stage := range markingStages(q) {
+		actions = append(actions, stage.actions...)
+	}
+
+	if len(actions) == 0 {
+		return MarkingAction{}, false
+	}
+
+	return actions[latestAction(len(actions), func(i int) *MarkingAction { return &actions[i] })], true
+}
+
+// Build returns the record. The builder can carry on being used, and
+// won't change records it has already returned.
+func (b *Builder) Build() PageData {
+	return copyPageData(b.pd)
+}
@@ -0,0 +1,167 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBuilderSources(b *Builder) *Builder {
+
+	n := 0
+	tick := time.Unix(1590000000, 0)
+
+	return b.WithUUIDs(func() string {
+		n++
+		return fmt.Sprintf("00000000-0000-4000-8000-%012d", n)
+	}).WithClock(func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	})
+}
+
+func TestBuilderNew(t *testing.T) {
+
+	pd := NewBuilder().
+		Exam(ExamDetails{CourseCode: "ENGI12123", Diet: "2020-Summer"}).
+		Author(AuthorDetails{Anonymous: "B12345"}).
+		Page(15, 20).
+		PreparedFor("marker").
+		ToDo("mark").
+		Build()
+
+	assert.Equal(t, "ENGI12123", pd.Exam.CourseCode)
+	assert.True(t, uuidRegexp.MatchString(pd.Exam.UUID))
+	assert.True(t, uuidRegexp.MatchString(pd.Page.UUID))
+	assert.NotEqual(t, pd.Exam.UUID, pd.Page.UUID)
+	assert.Equal(t, 15, pd.Page.Number)
+	assert.Equal(t, 0, pd.Revision)
+	assert.Empty(t, Validate(pd))
+	assert.NoError(t, ValidateSchema(pd))
+
+	// given UUID is kept
+	pd = NewBuilder().Exam(ExamDetails{UUID: "69197384-fd15-42ac-ac16-82dbe4d52dd0"}).Build()
+	assert.Equal(t, "69197384-fd15-42ac-ac16-82dbe4d52dd0", pd.Exam.UUID)
+}
+
+func TestBuilderProcess(t *testing.T) {
+
+	b := testBuilderSources(NewBuilder())
+
+	pd := b.Process("split", ContactDetails{Name: "gradex"},
+		ParameterDetails{Name: "dpi", Value: "300"},
+		ParameterDetails{Name: "scale", Value: "1"}).
+		Process("mark", ContactDetails{Name: "marker"}).
+		Build()
+
+	assert.Equal(t, 2, len(pd.Processing))
+
+	split, mark := pd.Processing[0], pd.Processing[1]
+
+	assert.Equal(t, "split", split.Name)
+	assert.Equal(t, 1, split.Sequence)
+	assert.Equal(t, "", split.Previous)
+	assert.Equal(t, []int{1, 2}, []int{split.Parameters[0].Sequence, split.Parameters[1].Sequence})
+
+	assert.Equal(t, 2, mark.Sequence)
+	assert.Equal(t, split.UUID, mark.Previous)
+	assert.True(t, mark.UnixTime > split.UnixTime)
+
+	last, err := SelectProcessByLast(pd)
+	assert.NoError(t, err)
+	assert.Equal(t, "mark", last.Name)
+}
+
+func TestBuilderQuestion(t *testing.T) {
+
+	pd := testBuilderSources(NewBuilder()).
+		Question(QuestionDetails{Name: "Q1", MarksAvailable: NewMark(5)}).
+		Question(QuestionDetails{Name: "Q2", MarksAvailable: NewMark(5), Sequence: 99}).
+		Build()
+
+	assert.Equal(t, 1, pd.Questions[0].Sequence)
+	assert.Equal(t, 2, pd.Questions[1].Sequence)
+	assert.Equal(t, pd.Questions[0].UUID, pd.Questions[1].Previous)
+}
+
+func TestBuilderMarking(t *testing.T) {
+
+	part := QuestionDetails{UUID: "00000000-0000-4000-9000-000000000001", Name: "1a"}
+
+	pd := testBuilderSources(NewBuilder()).
+		Question(QuestionDetails{Name: "Q1", Parts: []QuestionDetails{part}}).
+		Marking(part.UUID, MarkingAction{Actor: "marker"}).
+		Checking(part.UUID, MarkingAction{Actor: "checker"}).
+		Marking("no-such-question", MarkingAction{Actor: "marker"}).
+		Build()
+
+	marked := pd.Questions[0].Parts[0]
+
+	if assert.Equal(t, 1, len(marked.Marking)) && assert.Equal(t, 1, len(marked.Checking)) {
+		assert.NotEqual(t, "", marked.Marking[0].UUID)
+		assert.Equal(t, "", marked.Marking[0].Previous)
+		assert.Equal(t, marked.Marking[0].UUID, marked.Checking[0].Previous)
+		assert.True(t, marked.Checking[0].UnixTime > marked.Marking[0].UnixTime)
+	}
+}
+
+func TestUpdateBuilder(t *testing.T) {
+
+	first := testBuilderSources(NewBuilder()).
+		Exam(ExamDetails{CourseCode: "ENGI12123"}).
+		Process("split", ContactDetails{}).
+		Build()
+
+	// steps added out of order must still link to the highest sequence
+	first.Processing = append([]ProcessingDetails{
+		ProcessingDetails{UUID: "00000000-0000-4000-8000-000000000099", Sequence: 5},
+	}, first.Processing...)
+
+	second := testBuilderSources(UpdateBuilder(first)).
+		Process("mark", ContactDetails{}).
+		Build()
+
+	assert.Equal(t, first.Revision+1, second.Revision)
+	assert.Equal(t, first.Exam, second.Exam)
+	assert.Equal(t, 2, len(first.Processing))
+	assert.Equal(t, 3, len(second.Processing))
+	assert.Equal(t, 5, first.Processing[0].Sequence)
+
+	mark := second.Processing[2]
+	assert.Equal(t, 6, mark.Sequence)
+	assert.Equal(t, "00000000-0000-4000-8000-000000000099", mark.Previous)
+}
+
+func TestCopyPageData(t *testing.T) {
+
+	var pd PageData
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &pd))
+	pd.Processing[0].HLC = &HLC{WallTime: 1, Node: "n"}
+	pd.Questions[0].Marking[0].Criteria = []CriterionAward{CriterionAward{Criterion: "c", Mark: markPtr(1.5)}}
+	pd.Questions[0].Moderating = []MarkingAction{}
+
+	out := copyPageData(pd)
+	assert.Equal(t, pd, out)
+
+	// empty and nil lists are kept as they were
+	assert.NotNil(t, out.Questions[0].Moderating)
+	assert.Nil(t, out.Questions[0].Checking)
+
+	// and nothing is shared
+	out.Questions[0].Parts[0].Name = "changed"
+	out.Questions[0].Marking[0].Mark.Given = NewMark(9)
+	*out.Questions[0].Marking[0].Criteria[0].Mark = NewMark(9)
+	out.Processing[0].HLC.Node = "changed"
+	out.Extra["schema"][0] = '6'
+	out.Exam.Extra["term"] = json.RawMessage(`"S2"`)
+
+	assert.Equal(t, "Q1a", pd.Questions[0].Parts[0].Name)
+	assert.Equal(t, NewMark(2), pd.Questions[0].Marking[0].Mark.Given)
+	assert.Equal(t, NewMark(1.5), *pd.Questions[0].Marking[0].Criteria[0].Mark)
+	assert.Equal(t, "n", pd.Processing[0].HLC.Node)
+	assert.Equal(t, json.RawMessage(`5`), pd.Extra["schema"])
+	assert.Equal(t, json.RawMessage(`"S1"`), pd.Exam.Extra["term"])
+}
@@ -0,0 +1,364 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/timdrysdale/unipdf/v3/creator"
+)
+
+// Canonical form is our own scheme, based on RFC 8785 JSON
+// Canonicalization Scheme (JCS) but not the same: object keys sorted by
+// UTF-16 code units, minimal string escaping and no whitespace, as JCS
+// has, but integers are written exactly, as their decimal digits, where
+// JCS would round them to a double. Other numbers are written the way
+// ECMAScript does, as in JCS. The two only differ for integers beyond
+// 2^53, such as UnixTime, but there a JCS library in another language
+// gives a different hash, so use this one. Identical records always
+// give identical bytes, whichever tool wrote them, so the canonical
+// form is what gets hashed, signed and compared. An empty list is
+// written as null, the same as a nil one, so that how a record was made,
+// e.g. decoded from [] or from protocol buffers, which can't tell the
+// two apart, doesn't change its canonical form.
+
+var ErrDuplicateKey = errors.New("duplicate object key")
+
+// Canonical returns the canonical JSON encoding of pd
+func Canonical(pd PageData) ([]byte, error) {
+
+	data, err := json.Marshal(nilEmptyLists(pd))
+	if err != nil {
+		return nil, err
+	}
+
+	return CanonicalJSON(data)
+}
+
+// Hash is the hex encoded SHA-256 of the canonical form
+func (pd PageData) Hash() (string, error) {
+
+	data, err := Canonical(pd)
+	if err != nil {
+		return "", err
+	}
+
+	return hashBytes(data), nil
+}
+
+// nilEmptyLists gives a deep copy of v, with its empty lists made nil
+func nilEmptyLists(v interface{}) interface{} {
+
+	src := reflect.ValueOf(v)
+	dst := reflect.New(src.Type()).Elem()
+
+	deepCopy(dst, src)
+	clearEmptyLists(dst)
+
+	return dst.Interface()
+}
+
+func clearEmptyLists(v reflect.Value) {
+
+	switch v.Kind() {
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			clearEmptyLists(v.Elem())
+		}
+
+	case reflect.Slice:
+		if v.Len() == 0 && !v.IsNil() && v.CanSet() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			clearEmptyLists(v.Index(i))
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				clearEmptyLists(v.Field(i))
+			}
+		}
+	}
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// MarshalCanonicalPageData is MarshalPageData, writing the canonical form
+func MarshalCanonicalPageData(c *creator.Creator, pd *PageData) error {
+	return MarshalPageDataWithOptions(c, pd, WriteOptions{Codec: JSONCodec{Canonical: true}})
+}
+
+// CanonicalJSON re-encodes any JSON text in canonical form
+func CanonicalJSON(data []byte) ([]byte, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+
+	err := canonicalValue(dec, &buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// nothing but whitespace allowed after the value
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func canonicalValue(dec *json.Decoder, buf *bytes.Buffer) error {
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+
+	case json.Delim:
+		switch v {
+		case '{':
+			return canonicalObject(dec, buf)
+		case '[':
+			return canonicalArray(dec, buf)
+		}
+		return fmt.Errorf("unexpected %v", v)
+
+	case json.Number:
+		s, err := canonicalNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+
+	case string:
+		canonicalString(v, buf)
+
+	case bool:
+		if v {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+
+	case nil:
+		buf.WriteString("null")
+	}
+
+	return nil
+}
+
+func canonicalObject(dec *json.Decoder, buf *bytes.Buffer) error {
+
+	members := make(map[string][]byte)
+	var keys []string
+
+	for dec.More() {
+
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected object key %v", tok)
+		}
+
+		if _, dup := members[key]; dup {
+			return ErrDuplicateKey
+		}
+
+		var value bytes.Buffer
+		if err := canonicalValue(dec, &value); err != nil {
+			return err
+		}
+
+		members[key] = value.Bytes()
+		keys = append(keys, key)
+	}
+
+	// closing brace
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return lessUTF16(keys[i], keys[j])
+	})
+
+	buf.WriteByte('{')
+
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		canonicalString(key, buf)
+		buf.WriteByte(':')
+		buf.Write(members[key])
+	}
+
+	buf.WriteByte('}')
+
+	return nil
+}
+
+func canonicalArray(dec *json.Decoder, buf *bytes.Buffer) error {
+
+	buf.WriteByte('[')
+
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := canonicalValue(dec, buf); err != nil {
+			return err
+		}
+	}
+
+	// closing bracket
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	buf.WriteByte(']')
+
+	return nil
+}
+
+// lessUTF16 compares strings by their UTF-16 code units, as JCS requires
+func lessUTF16(a, b string) bool {
+
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+
+	return len(ua) < len(ub)
+}
+
+func canonicalString(s string, buf *bytes.Buffer) {
+
+	buf.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+
+	buf.WriteByte('"')
+}
+
+// canonicalNumber writes whole numbers that fit in an int64 exactly,
+// because UnixTime holds nanoseconds, which a double can't represent.
+// Anything else is written as an IEEE 754 double, formatted like
+// ECMAScript's Number.prototype.toString.
+func canonicalNumber(n json.Number) (string, error) {
+
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return strconv.FormatInt(i, 10), nil
+	}
+
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return "", err
+	}
+
+	return formatES6(f)
+}
+
+func formatES6(f float64) (string, error) {
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("number not representable in JSON")
+	}
+
+	if f == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// shortest round-trip digits, as d.ddddde±x
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp := e[:strings.IndexByte(e, 'e')], e[strings.IndexByte(e, 'e')+1:]
+
+	digits := strings.Replace(mantissa, ".", "", 1)
+	x, err := strconv.Atoi(exp)
+	if err != nil {
+		return "", err
+	}
+
+	k := len(digits)
+	n := x + 1 // position of the decimal point relative to the digits
+
+	var s string
+
+	switch {
+	case k <= n && n <= 21:
+		s = digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		s = digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		s = "0." + strings.Repeat("0", -n) + digits
+	default:
+		s = digits[:1]
+		if k > 1 {
+			s += "." + digits[1:]
+		}
+		if n-1 >= 0 {
+			s += "e+" + strconv.Itoa(n-1)
+		} else {
+			s += "e-" + strconv.Itoa(1-n)
+		}
+	}
+
+	return sign + s, nil
+}
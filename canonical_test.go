@@ -0,0 +1,145 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalJSONRFC8785(t *testing.T) {
+
+	// examples from RFC 8785 section 3.2.2 and 3.2.3
+	input := `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	out, err := CanonicalJSON([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(out))
+
+	input = `{
+  "€": "Euro Sign",
+  "\r": "Carriage Return",
+  "דּ": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "😀": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "ö": "Latin Small Letter O With Diaeresis"
+}`
+	expected = `{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","דּ":"Hebrew Letter Dalet With Dagesh"}`
+
+	out, err = CanonicalJSON([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(out))
+
+	_, err = CanonicalJSON([]byte(`{"a":1,"a":2}`))
+	assert.Equal(t, ErrDuplicateKey, err)
+
+	_, err = CanonicalJSON([]byte(`{"a":1} {}`))
+	assert.Error(t, err)
+
+	_, err = CanonicalJSON([]byte(`1e400`))
+	assert.Error(t, err)
+}
+
+func TestCanonicalIntegers(t *testing.T) {
+
+	// JCS would give 9007199254740992 and 1596024000000000000 for these
+	out, err := CanonicalJSON([]byte(`[9007199254740993, 1596024000000000123, -9007199254740993, 2.0, 1e2]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[9007199254740993,1596024000000000123,-9007199254740993,2,100]`, string(out))
+
+	// beyond an int64 they are doubles, as in JCS
+	out, err = CanonicalJSON([]byte(`[9223372036854775808]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[9223372036854776000]`, string(out))
+}
+
+func TestFormatES6(t *testing.T) {
+
+	// from RFC 8785 appendix B
+	cases := map[float64]string{
+		0:                      "0",
+		math.Copysign(0, -1):   "0",
+		1:                      "1",
+		-1.5:                   "-1.5",
+		5e-324:                 "5e-324",
+		1.7976931348623157e308: "1.7976931348623157e+308",
+		9007199254740992:       "9007199254740992",
+		-9007199254740992:      "-9007199254740992",
+		295147905179352830000:  "295147905179352830000",
+		1e21:                   "1e+21",
+		9.999999999999997e22:   "9.999999999999997e+22",
+		0.000001:               "0.000001",
+		0.0000001:              "1e-7",
+		123456789012345680000:  "123456789012345680000",
+		0.30000000000000004:    "0.30000000000000004",
+	}
+
+	for f, expected := range cases {
+		s, err := formatES6(f)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, s)
+	}
+
+	_, err := formatES6(math.Inf(1))
+	assert.Error(t, err)
+}
+
+func TestCanonicalPageData(t *testing.T) {
+
+	pd := PageData{
+		Exam:     ExamDetails{CourseCode: "ENGI12123", UUID: "e1"},
+		Revision: 3,
+		Questions: []QuestionDetails{
+			QuestionDetails{Name: "Q1", MarksAvailable: NewMark(4.5)},
+		},
+	}
+
+	a, err := Canonical(pd)
+	assert.NoError(t, err)
+
+	// same record from another tool, different key order and spacing
+	other := `{ "revision": 3, "exam": {"UUID":"e1", "courseCode":"ENGI12123","diet":"","date":""},
+	  "questions":[{"marksAvailable":45e-1,"name":"Q1","UUID":"","section":"","number":0,"parts":null,
+	  "marksAwarded":0,"markers":null,"moderators":null,"checkers":null,"sequence":0,"unixTime":0,"previous":""}],
+	  "author":{"Anonymous":"","Identity":""},"page":{"UUID":"","number":0,"of":0,"filename":""},
+	  "contact":{"name":"","UUID":"","email":"","address":""},
+	  "submission":{"filePrefix":"","originalFilename":"","originalFormat":"","newFilename":"","newFormat":""},
+	  "processing":null,"custom":null,"preparedfor":"","todo":""}`
+
+	var decoded PageData
+	assert.NoError(t, json.Unmarshal([]byte(other), &decoded))
+
+	b, err := Canonical(decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, string(a), string(b))
+
+	c, err := CanonicalJSON([]byte(other))
+	assert.NoError(t, err)
+	assert.Equal(t, string(a), string(c))
+
+	ha, err := pd.Hash()
+	assert.NoError(t, err)
+	hb, err := decoded.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, ha, hb)
+	assert.Equal(t, 64, len(ha))
+
+	decoded.Revision = 4
+	hc, err := decoded.Hash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, ha, hc)
+}
+
+func TestCanonicalKeepsNanoseconds(t *testing.T) {
+
+	out, err := CanonicalJSON([]byte(`[1590000000123456789, -0, 1.0, 1e2, 9007199254740993.0]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[1590000000123456789,0,1,100,9007199254740992]`, string(out))
+}
@@ -0,0 +1,369 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Each processing step, each question and each marking action names the
+// one before it in Previous, and records the hash of its canonical form
+// in PreviousHash, so they form chains, like a git history. Editing an
+// old step changes its hash, which no longer matches what the next step
+// recorded. The Builder fills these in.
+//
+// Steps are never changed once recorded. When Merge has to renumber the
+// steps from one side, it adds a merge step that records the new numbers
+// (see StepSequences), and names the last step on the other side, and its
+// hash, as a second parent, which is checked like Previous.
+//
+// Questions are marked in place, so a question's hash covers what it is
+// (name, number, marks available, ...) but not the marks awarded or the
+// marking actions, else marking a question would break the chain. The
+// marking, moderating and checking actions on each question form one
+// chain of their own instead, so a changed mark given shows up as an
+// edit. The question's own MarksAwarded is not covered.
+//
+// The last link in each chain has nothing after it to record its hash,
+// so an edit to it is only caught against a copy in another record.
+//
+// VerifyChain walks the chains in every record found for a page, and
+// reports:
+//   gaps     a step names a predecessor that is not there
+//   forks    two different steps follow the same one
+//   reorders a step is sequenced (or stamped) before its predecessor
+//   edits    a predecessor doesn't match the hash recorded for it, or
+//            the same step differs between records
+// Steps from before hashes were recorded have no PreviousHash, so their
+// links are only checked for gaps, forks and order.
+
+type ChainIssue int
+
+const (
+	ChainGap     ChainIssue = iota
+	ChainFork    ChainIssue = iota
+	ChainReorder ChainIssue = iota
+	ChainEdit    ChainIssue = iota
+)
+
+func (i ChainIssue) String() string {
+	switch i {
+	case ChainGap:
+		return "gap"
+	case ChainFork:
+		return "fork"
+	case ChainReorder:
+		return "reorder"
+	case ChainEdit:
+		return "edit"
+	}
+	return fmt.Sprintf("issue(%d)", int(i))
+}
+
+// ChainProblem is one issue, found in the record at index Record of the
+// slice given to VerifyChain, at Path in that record
+type ChainProblem struct {
+	Issue   ChainIssue
+	Record  int
+	Path    string
+	Message string
+}
+
+func (p ChainProblem) String() string {
+	return fmt.Sprintf("%s in record %d at %s: %s", p.Issue, p.Record, p.Path, p.Message)
+}
+
+// StepHash is the hex SHA-256 of the step's canonical form
+func StepHash(step ProcessingDetails) (string, error) {
+	return canonicalHash(step)
+}
+
+// QuestionHash is the hex SHA-256 of the canonical form of the question,
+// leaving out its marks, marking actions and parts
+func QuestionHash(q QuestionDetails) (string, error) {
+
+	q.MarksAwarded = Mark{}
+	q.Marking = nil
+	q.Moderating = nil
+	q.Checking = nil
+	q.Parts = nil
+
+	return canonicalHash(q)
+}
+
+// ActionHash is the hex SHA-256 of the marking action's canonical form
+func ActionHash(a MarkingAction) (string, error) {
+	return canonicalHash(a)
+}
+
+func canonicalHash(v interface{}) (string, error) {
+
+	data, err := json.Marshal(nilEmptyLists(v))
+	if err != nil {
+		return "", err
+	}
+
+	canonical, err := CanonicalJSON(data)
+	if err != nil {
+		return "", err
+	}
+
+	return hashBytes(canonical), nil
+}
+
+// chainLink is a step or question, reduced to what the chain needs
+type chainLink struct {
+	record       int
+	path         string
+	uuid         string
+	previous     string
+	previousHash string
+	hash         string
+	sequence     int
+	unixTime     int64
+	hlc          *HLC
+}
+
+// VerifyChain checks the processing, question and marking action chains
+// across all the records for one page, e.g. one entry of
+// GetPageDataFromFile's results
+func VerifyChain(pds []PageData) ([]ChainProblem, error) {
+
+	var steps, questions, actions []chainLink
+
+	for r, pd := range pds {
+
+		seqs := StepSequences(pd.Processing)
+
+		for i, step := range pd.Processing {
+
+			hash, err := StepHash(step)
+			if err != nil {
+				return nil, err
+			}
+
+			link := chainLink{
+				record:       r,
+				path:         fmt.Sprintf("/processing/%d", i),
+				uuid:         step.UUID,
+				previous:     step.Previous,
+				previousHash: step.PreviousHash,
+				hash:         hash,
+				sequence:     seqs[i],
+				unixTime:     step.UnixTime,
+				hlc:          step.HLC,
+			}
+
+			steps = append(steps, link)
+
+			// a merge step's second parent is checked as another copy
+			// of the step, with a different previous
+			for j, param := range step.Parameters {
+				if param.Name == MergeParentParameter {
+					parent := link
+					parent.path = fmt.Sprintf("%s/parameters/%d", link.path, j)
+					parent.previous = param.Value
+					parent.previousHash, _ = stepParameter(step, MergeParentHashParameter)
+					steps = append(steps, parent)
+				}
+			}
+		}
+
+		for i, q := range pd.Questions {
+
+			path := fmt.Sprintf("/questions/%d", i)
+
+			hash, err := QuestionHash(q)
+			if err != nil {
+				return nil, err
+			}
+
+			questions = append(questions, chainLink{
+				record:       r,
+				path:         path,
+				uuid:         q.UUID,
+				previous:     q.Previous,
+				previousHash: q.PreviousHash,
+				hash:         hash,
+				sequence:     q.Sequence,
+				unixTime:     q.UnixTime,
+				hlc:          q.HLC,
+			})
+
+			if actions, err = actionLinks(r, path, q, actions); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var problems []ChainProblem
+
+	problems = append(problems, verifyLinks("step", steps)...)
+	problems = append(problems, verifyLinks("question", questions)...)
+	problems = append(problems, verifyLinks("marking action", actions)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Record != problems[j].Record {
+			return problems[i].Record < problems[j].Record
+		}
+		return problems[i].Path < problems[j].Path
+	})
+
+	return problems, nil
+}
+
+// actionLinks adds the links for the marking actions on the question,
+// and its parts, to those found so far
+func actionLinks(record int, path string, q QuestionDetails, links []chainLink) ([]chainLink, error) {
+
+	for _, list := range []struct {
+		name    string
+		actions []MarkingAction
+	}{
+		{"markers", q.Marking},
+		{"moderators", q.Moderating},
+		{"checkers", q.Checking},
+	} {
+		for i, a := range list.actions {
+
+			hash, err := ActionHash(a)
+			if err != nil {
+				return nil, err
+			}
+
+			links = append(links, chainLink{
+				record:       record,
+				path:         fmt.Sprintf("%s/%s/%d", path, list.name, i),
+				uuid:         a.UUID,
+				previous:     a.Previous,
+				previousHash: a.PreviousHash,
+				hash:         hash,
+				unixTime:     a.UnixTime,
+				hlc:          a.HLC,
+			})
+		}
+	}
+
+	for i, part := range q.Parts {
+		var err error
+		if links, err = actionLinks(record, fmt.Sprintf("%s/parts/%d", path, i), part, links); err != nil {
+			return nil, err
+		}
+	}
+
+	return links, nil
+}
+
+// VerifyPageChain is VerifyChain for a single record
+func VerifyPageChain(pd PageData) ([]ChainProblem, error) {
+	return VerifyChain([]PageData{pd})
+}
+
+func verifyLinks(what string, links []chainLink) []ChainProblem {
+
+	var problems []ChainProblem
+
+	report := func(issue ChainIssue, l chainLink, format string, args ...interface{}) {
+		problems = append(problems, ChainProblem{
+			Issue:   issue,
+			Record:  l.record,
+			Path:    l.path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// the same step can be in many records, but should be identical
+	byUUID := make(map[string]chainLink)
+	var distinct []chainLink
+
+	for _, l := range links {
+
+		if l.uuid == "" {
+			continue
+		}
+
+		first, seen := byUUID[l.uuid]
+		if !seen {
+			byUUID[l.uuid] = l
+			distinct = append(distinct, l)
+			continue
+		}
+
+		if first.hash != l.hash {
+			report(ChainEdit, l, "%s %s differs from the copy in record %d at %s", what, l.uuid, first.record, first.path)
+		}
+	}
+
+	// each record's own copy of each step, to find one missing from a
+	// record, and to check hashes against the copy the step was built on
+	type recordUUID struct {
+		record int
+		uuid   string
+	}
+
+	inRecord := make(map[recordUUID]chainLink)
+	for _, l := range links {
+		inRecord[recordUUID{l.record, l.uuid}] = l
+	}
+
+	for _, l := range links {
+
+		if l.previous == "" {
+			if l.previousHash != "" {
+				report(ChainGap, l, "%s %s has a previous hash, but no previous %s", what, l.uuid, what)
+			}
+			continue
+		}
+
+		prev, ok := inRecord[recordUUID{l.record, l.previous}]
+		if !ok {
+			if prev, ok = byUUID[l.previous]; !ok {
+				report(ChainGap, l, "previous %s %s not found", what, l.previous)
+				continue
+			}
+			report(ChainGap, l, "previous %s %s is missing from this record", what, l.previous)
+		}
+
+		if l.previousHash != "" && l.previousHash != prev.hash {
+			report(ChainEdit, l, "previous %s %s has been changed since this was recorded", what, l.previous)
+		}
+
+		if !after(l, prev) {
+			report(ChainReorder, l, "%s %s comes before the %s %s it follows", what, l.uuid, what, l.previous)
+		}
+	}
+
+	// forks: more than one distinct successor of the same step
+	successors := make(map[string][]chainLink)
+	for _, l := range distinct {
+		if l.previous != "" {
+			successors[l.previous] = append(successors[l.previous], l)
+		}
+	}
+
+	for _, l := range distinct {
+		if next := successors[l.uuid]; len(next) > 1 {
+			for _, n := range next[1:] {
+				report(ChainFork, n, "%s %s and %s both follow %s", what, next[0].uuid, n.uuid, l.uuid)
+			}
+		}
+	}
+
+	return problems
+}
+
+// after reports whether l is ordered after prev, by HLC if both have
+// one, else by sequence, else (for marking actions, which have none) by
+// time
+func after(l, prev chainLink) bool {
+
+	if l.hlc != nil && prev.hlc != nil {
+		return l.hlc.Compare(*prev.hlc) > 0
+	}
+
+	if l.sequence != 0 || prev.sequence != 0 {
+		return l.sequence > prev.sequence
+	}
+
+	return l.unixTime >= prev.unixTime
+}
@@ -0,0 +1,243 @@
+package pdfpagedata
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func chainPageData() PageData {
+
+	by := ContactDetails{Name: "gradex"}
+
+	return testBuilderSources(NewBuilder()).
+		Page(1, 2).
+		Process("split", by).
+		Process("flatten", by).
+		Process("mark", by).
+		Question(QuestionDetails{Name: "1a", MarksAvailable: NewMark(4)}).
+		Question(QuestionDetails{Name: "1b", MarksAvailable: NewMark(6)}).
+		Build()
+}
+
+// prefixUUIDs gives each builder its own UUIDs, so updates to the same
+// record don't reuse those from testBuilderSources
+func prefixUUIDs(b *Builder, prefix int) *Builder {
+
+	n := 0
+
+	return b.WithUUIDs(func() string {
+		n++
+		return fmt.Sprintf("00000000-0000-4000-%04d-%012d", prefix, n)
+	})
+}
+
+func chainIssues(problems []ChainProblem) []string {
+
+	var issues []string
+
+	for _, p := range problems {
+		issues = append(issues, fmt.Sprintf("%s %d %s", p.Issue, p.Record, p.Path))
+	}
+
+	return issues
+}
+
+func TestChainBuilt(t *testing.T) {
+
+	pd := chainPageData()
+
+	hash, err := StepHash(pd.Processing[0])
+	assert.NoError(t, err)
+	assert.Equal(t, hash, pd.Processing[1].PreviousHash)
+	assert.Equal(t, "", pd.Processing[0].PreviousHash)
+
+	hash, err = QuestionHash(pd.Questions[0])
+	assert.NoError(t, err)
+	assert.Equal(t, hash, pd.Questions[1].PreviousHash)
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestChainMarkingKeepsHash(t *testing.T) {
+
+	pd := chainPageData()
+
+	pd.Questions[0].MarksAwarded = NewMark(3)
+	pd.Questions[0].Marking = []MarkingAction{
+		MarkingAction{Actor: "marker", Done: true},
+	}
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	pd.Questions[0].MarksAvailable = NewMark(5)
+
+	problems, err = VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"edit 0 /questions/1"}, chainIssues(problems))
+}
+
+func TestChainMarkingActions(t *testing.T) {
+
+	base := chainPageData()
+	q := base.Questions[0].UUID
+
+	pd := prefixUUIDs(UpdateBuilder(base), 1).
+		Marking(q, MarkingAction{Actor: "marker", Mark: MarkDetails{Given: NewMark(3)}, Done: true}).
+		Moderating(q, MarkingAction{Actor: "moderator", Mark: MarkDetails{Given: NewMark(2)}, Done: true}).
+		Build()
+
+	marking, moderating := pd.Questions[0].Marking[0], pd.Questions[0].Moderating[0]
+
+	hash, err := ActionHash(marking)
+	assert.NoError(t, err)
+	assert.Equal(t, marking.UUID, moderating.Previous)
+	assert.Equal(t, hash, moderating.PreviousHash)
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	// a mark changed after it was moderated
+	edited := copyPageData(pd)
+	edited.Questions[0].Marking[0].Mark.Given = NewMark(4)
+
+	problems, err = VerifyPageChain(edited)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"edit 0 /questions/0/moderators/0"}, chainIssues(problems))
+
+	// the last action is only caught against another copy
+	edited = copyPageData(pd)
+	edited.Questions[0].Moderating[0].Mark.Given = NewMark(4)
+
+	problems, err = VerifyPageChain(edited)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	problems, err = VerifyChain([]PageData{pd, edited})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"edit 1 /questions/0/moderators/0"}, chainIssues(problems))
+}
+
+func TestChainEdit(t *testing.T) {
+
+	pd := chainPageData()
+	pd.Processing[0].Name = "not-split"
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"edit 0 /processing/1"}, chainIssues(problems))
+}
+
+func TestChainGap(t *testing.T) {
+
+	pd := chainPageData()
+	pd.Processing = append(pd.Processing[:1], pd.Processing[2:]...)
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gap 0 /processing/1"}, chainIssues(problems))
+}
+
+func TestChainReorder(t *testing.T) {
+
+	pd := chainPageData()
+	pd.Processing[2].Sequence = 1
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"reorder 0 /processing/2"}, chainIssues(problems))
+}
+
+func TestChainAcrossRecords(t *testing.T) {
+
+	base := chainPageData()
+	by := ContactDetails{Name: "marker"}
+
+	ours := prefixUUIDs(UpdateBuilder(base), 1).Process("moderate", by).Build()
+	theirs := prefixUUIDs(UpdateBuilder(base), 2).Process("check", by).Build()
+
+	problems, err := VerifyChain([]PageData{base, ours})
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+
+	problems, err = VerifyChain([]PageData{base, ours, theirs})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fork 2 /processing/3"}, chainIssues(problems))
+
+	// an edit to one copy shows up against the others
+	ours.Processing[1].Name = "not-flatten"
+
+	problems, err = VerifyChain([]PageData{base, ours})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"edit 1 /processing/1", "edit 1 /processing/2"}, chainIssues(problems))
+
+	// a record missing a step that the others have
+	ours = prefixUUIDs(UpdateBuilder(base), 1).Process("moderate", by).Build()
+	ours.Processing = append(ours.Processing[:2], ours.Processing[3])
+
+	problems, err = VerifyChain([]PageData{base, ours})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gap 1 /processing/2"}, chainIssues(problems))
+}
+
+func TestChainAfterMerge(t *testing.T) {
+
+	base := chainPageData()
+	by := ContactDetails{Name: "marker"}
+
+	ours := prefixUUIDs(UpdateBuilder(base), 1).Process("moderate", by).Build()
+	theirs := prefixUUIDs(UpdateBuilder(base), 2).Process("check", by).Process("release", by).Build()
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	// their steps are renumbered after ours by a merge step
+	if assert.Equal(t, 7, len(merged.Processing)) {
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, StepSequences(merged.Processing))
+		assert.Equal(t, MergeStepName, merged.Processing[6].Name)
+	}
+
+	// the branches fork, but nothing reads as edited or out of order
+	problems, err := VerifyPageChain(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fork 0 /processing/4"}, chainIssues(problems))
+
+	problems, err = VerifyChain([]PageData{base, ours, theirs, merged})
+	assert.NoError(t, err)
+	for _, p := range problems {
+		assert.Equal(t, ChainFork, p.Issue, p.String())
+	}
+
+	// the merge step covers the head of their branch too
+	merged.Processing[5].Name = "not-release"
+
+	problems, err = VerifyPageChain(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fork 0 /processing/4", "edit 0 /processing/6/parameters/0"}, chainIssues(problems))
+}
+
+func TestChainLegacy(t *testing.T) {
+
+	pd := chainPageData()
+
+	for i := range pd.Processing {
+		pd.Processing[i].PreviousHash = ""
+	}
+
+	for i := range pd.Questions {
+		pd.Questions[i].PreviousHash = ""
+	}
+
+	pd.Processing[0].Name = "not-split"
+
+	problems, err := VerifyPageChain(pd)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
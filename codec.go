@@ -0,0 +1,609 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// A Codec turns a PageData into bytes and back. JSON tokens are written
+// bare, as they always have been, so older readers can still use them.
+// Every other codec's token is its ID, a tilde, then the bytes in base85,
+// e.g. cbor~tq3Ea... so a document can mix codecs and still decode.
+// Base85 takes five characters for every four bytes, where base64 takes
+// five and a third, and its alphabet leaves out \, < and >, which would
+// be escaped on the page. Tokens from before, with a colon
+// and base64, e.g. cbor:pWRleGFt... still decode.
+type Codec interface {
+	ID() string
+	Marshal(pd *PageData) ([]byte, error)
+	Unmarshal(data []byte, pd *PageData) error
+}
+
+var (
+	ErrUnknownCodec = errors.New("unknown codec")
+	ErrBadCodecID   = errors.New("codec ID must be lower case letters or digits")
+	ErrBuiltInCodec = errors.New("codec ID is taken by a built-in codec")
+	ErrBadBase85    = errors.New("token is not valid base85")
+)
+
+var codecIDRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+
+var codecs = map[string]Codec{
+	"json":    JSONCodec{},
+	"cbor":    CBORCodec{},
+	"msgpack": MsgpackCodec{},
+}
+
+// codecsMutex guards codecs, since codecs can be registered while
+// other goroutines are reading pages
+var codecsMutex sync.RWMutex
+
+func builtInCodec(id string) bool {
+	switch id {
+	case "json", "cbor", "msgpack":
+		return true
+	}
+	return false
+}
+
+func lookupCodec(id string) (Codec, bool) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+	codec, ok := codecs[id]
+	return codec, ok
+}
+
+// legacyTokenEncoding is how tokens were armoured before base85
+var legacyTokenEncoding = base64.RawStdEncoding
+
+const base85Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&~|()[]{}@%$#"
+
+var base85Digits = func() [256]int {
+
+	var digits [256]int
+
+	for i := range digits {
+		digits[i] = -1
+	}
+
+	for i := 0; i < len(base85Alphabet); i++ {
+		digits[base85Alphabet[i]] = i
+	}
+
+	return digits
+}()
+
+// encodeBase85 writes each four bytes as five digits, big end first. A
+// last group of n < 4 bytes is padded with zeros, and only its first
+// n+1 digits written.
+func encodeBase85(data []byte) string {
+
+	var sb strings.Builder
+
+	for len(data) > 0 {
+
+		var group [4]byte
+		n := copy(group[:], data)
+		data = data[n:]
+
+		v := binary.BigEndian.Uint32(group[:])
+
+		var digits [5]byte
+		for i := 4; i >= 0; i-- {
+			digits[i] = base85Alphabet[v%85]
+			v /= 85
+		}
+
+		sb.Write(digits[:n+1])
+	}
+
+	return sb.String()
+}
+
+// decodeBase85 undoes encodeBase85, padding a short last group with the
+// highest digit, so that it rounds back up to the bytes it came from
+func decodeBase85(s string) ([]byte, error) {
+
+	var data []byte
+
+	for len(s) > 0 {
+
+		n := len(s)
+		if n > 5 {
+			n = 5
+		}
+
+		if n == 1 {
+			return nil, ErrBadBase85
+		}
+
+		var v uint64
+
+		for i := 0; i < 5; i++ {
+			d := len(base85Alphabet) - 1
+			if i < n {
+				if d = base85Digits[s[i]]; d < 0 {
+					return nil, ErrBadBase85
+				}
+			}
+			v = v*85 + uint64(d)
+		}
+
+		if v > math.MaxUint32 {
+			return nil, ErrBadBase85
+		}
+
+		var group [4]byte
+		binary.BigEndian.PutUint32(group[:], uint32(v))
+
+		data = append(data, group[:n-1]...)
+		s = s[n:]
+	}
+
+	return data, nil
+}
+
+// RegisterCodec makes a codec available for decoding, by its ID. The
+// built-in codecs can't be replaced. It is safe to call concurrently.
+func RegisterCodec(codec Codec) error {
+
+	id := codec.ID()
+
+	if !codecIDRegexp.MatchString(id) {
+		return ErrBadCodecID
+	}
+
+	if builtInCodec(id) {
+		return ErrBuiltInCodec
+	}
+
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs[id] = codec
+
+	return nil
+}
+
+// EncodeToken marshals pd with codec, ready to go between tags
+func EncodeToken(pd *PageData, codec Codec) (string, error) {
+
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+
+	data, err := codec.Marshal(pd)
+	if err != nil {
+		return "", err
+	}
+
+	if codec.ID() == "json" {
+		return string(data), nil
+	}
+
+	return codec.ID() + "~" + encodeBase85(data), nil
+}
+
+// DecodeToken unmarshals a token written by any registered codec
+func DecodeToken(token string, pd *PageData) error {
+
+	codec, data, err := splitToken(token)
+	if err != nil {
+		return err
+	}
+
+	return codec.Unmarshal(data, pd)
+}
+
+// splitToken finds the codec for a token, and its undecoded bytes
+func splitToken(token string) (Codec, []byte, error) {
+
+	trimmed := strings.TrimSpace(token)
+
+	if strings.HasPrefix(trimmed, "{") {
+		return JSONCodec{}, []byte(trimmed), nil
+	}
+
+	// codec IDs have neither, so the first is the separator
+	i := strings.IndexAny(trimmed, "~:")
+	if i < 0 {
+		return nil, nil, ErrUnknownCodec
+	}
+
+	codec, ok := lookupCodec(trimmed[:i])
+	if !ok {
+		return nil, nil, ErrUnknownCodec
+	}
+
+	decode := decodeBase85
+	if trimmed[i] == ':' {
+		decode = legacyTokenEncoding.DecodeString
+	}
+
+	data, err := decode(trimmed[i+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return codec, data, nil
+}
+
+// wellFormed reports whether the token could be decoded, without decoding it
+func wellFormed(token string) bool {
+
+	codec, data, err := splitToken(token)
+	if err != nil {
+		return false
+	}
+
+	if codec.ID() == "json" {
+		return json.Valid(data)
+	}
+
+	return true
+}
+
+// JSONCodec is the default. Canonical output (see Canonical) is optional
+// because it is slower, but means identical records are identical tokens.
+type JSONCodec struct {
+	Canonical bool
+}
+
+func (c JSONCodec) ID() string {
+	return "json"
+}
+
+func (c JSONCodec) Marshal(pd *PageData) ([]byte, error) {
+	if c.Canonical {
+		return Canonical(*pd)
+	}
+	return json.Marshal(pd)
+}
+
+func (c JSONCodec) Unmarshal(data []byte, pd *PageData) error {
+	return json.Unmarshal(data, pd)
+}
+
+// The binary codecs go via the JSON form, so that they keep unknown
+// fields just the same. Each field name this version knows is written
+// as its index in compactKeys, which CBOR and msgpack both write in a
+// byte or two, rather than in full; unknown fields keep their names.
+// Keys are sorted, so the output is deterministic. Tokens from before
+// keys were compacted, with every name in full, still decode.
+
+// compactKeys must only ever be added to, at the end, since the tokens
+// already written depend on the index of each key. The keys most often
+// repeated come first, since CBOR writes 0 to 23 in a single byte.
+var compactKeys = []string{
+	"UUID", "name", "unixTime", "sequence", "previous", "previousHash",
+	"hlc", "wallTime", "logical", "node", "contact", "email",
+	"address", "actor", "mark", "given", "available", "comment",
+	"done", "custom", "value", "by", "parameters", "number",
+	"section", "parts", "marksAvailable", "marksAwarded", "markers", "moderators",
+	"checkers", "rubric", "criteria", "criterion", "band", "id",
+	"descriptor", "marks", "bands", "min", "max", "exam",
+	"courseCode", "diet", "date", "author", "Anonymous", "Identity",
+	"page", "of", "filename", "submission", "filePrefix", "originalFilename",
+	"originalFormat", "newFilename", "newFormat", "questions", "processing", "revision",
+	"preparedfor", "todo",
+}
+
+var compactKeyIndex = func() map[string]uint64 {
+
+	index := make(map[string]uint64)
+
+	for i, key := range compactKeys {
+		index[key] = uint64(i)
+	}
+
+	return index
+}()
+
+type CBORCodec struct{}
+
+func (c CBORCodec) ID() string {
+	return "cbor"
+}
+
+func (c CBORCodec) Marshal(pd *PageData) ([]byte, error) {
+
+	v, err := genericValue(pd)
+	if err != nil {
+		return nil, err
+	}
+
+	em, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		return nil, err
+	}
+
+	return em.Marshal(compactKeysOf(v))
+}
+
+func (c CBORCodec) Unmarshal(data []byte, pd *PageData) error {
+
+	var v interface{}
+	if err := cbor.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	return fromCompactValue(v, pd)
+}
+
+type MsgpackCodec struct{}
+
+func (c MsgpackCodec) ID() string {
+	return "msgpack"
+}
+
+func (c MsgpackCodec) Marshal(pd *PageData) ([]byte, error) {
+
+	v, err := genericValue(pd)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+
+	if err := encodeMsgpack(enc, compactKeysOf(v)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c MsgpackCodec) Unmarshal(data []byte, pd *PageData) error {
+
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return err
+	}
+
+	return fromCompactValue(v, pd)
+}
+
+// encodeMsgpack writes maps with their keys sorted, numbers before names,
+// which msgpack only does itself for maps keyed by strings
+func encodeMsgpack(enc *msgpack.Encoder, v interface{}) error {
+
+	switch t := v.(type) {
+
+	case map[interface{}]interface{}:
+
+		keys := make([]interface{}, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+
+		sort.Slice(keys, func(i, j int) bool {
+			a, aIndex := keys[i].(uint64)
+			b, bIndex := keys[j].(uint64)
+			switch {
+			case aIndex && bIndex:
+				return a < b
+			case aIndex != bIndex:
+				return aIndex
+			}
+			return keys[i].(string) < keys[j].(string)
+		})
+
+		if err := enc.EncodeMapLen(len(keys)); err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := enc.Encode(k); err != nil {
+				return err
+			}
+			if err := encodeMsgpack(enc, t[k]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case []interface{}:
+
+		if err := enc.EncodeArrayLen(len(t)); err != nil {
+			return err
+		}
+
+		for _, e := range t {
+			if err := encodeMsgpack(enc, e); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return enc.Encode(v)
+}
+
+// genericValue is pd as maps, slices and scalars, with whole
+// numbers as integers so the binary encodings stay compact
+func genericValue(pd *PageData) (interface{}, error) {
+
+	data, err := json.Marshal(pd)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return compactNumbers(v), nil
+}
+
+func compactNumbers(v interface{}) interface{} {
+
+	switch t := v.(type) {
+
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = compactNumbers(e)
+		}
+
+	case []interface{}:
+		for i, e := range t {
+			t[i] = compactNumbers(e)
+		}
+
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil && !math.IsInf(f, 0) {
+			return f
+		}
+		return t.String()
+	}
+
+	return v
+}
+
+// compactKeysOf swaps the keys in compactKeys for their index
+func compactKeysOf(v interface{}) interface{} {
+
+	switch t := v.(type) {
+
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(t))
+		for k, e := range t {
+			if i, ok := compactKeyIndex[k]; ok {
+				m[i] = compactKeysOf(e)
+			} else {
+				m[k] = compactKeysOf(e)
+			}
+		}
+		return m
+
+	case []interface{}:
+		for i, e := range t {
+			t[i] = compactKeysOf(e)
+		}
+	}
+
+	return v
+}
+
+// expandKeys swaps indexes back for the keys in compactKeys, so that the
+// value can go back through JSON. Keys written in full, by this version
+// for unknown fields or by older ones for all of them, are kept.
+func expandKeys(v interface{}) (interface{}, error) {
+
+	switch t := v.(type) {
+
+	case map[interface{}]interface{}:
+
+		m := make(map[string]interface{}, len(t))
+
+		for k, e := range t {
+
+			key, ok := k.(string)
+			if !ok {
+				i, isIndex := keyIndex(k)
+				if !isIndex || i >= uint64(len(compactKeys)) {
+					return nil, fmt.Errorf("unknown compact key %v", k)
+				}
+				key = compactKeys[i]
+			}
+
+			expanded, err := expandKeys(e)
+			if err != nil {
+				return nil, err
+			}
+
+			m[key] = expanded
+		}
+
+		return m, nil
+
+	case map[string]interface{}:
+		for k, e := range t {
+			expanded, err := expandKeys(e)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = expanded
+		}
+
+	case []interface{}:
+		for i, e := range t {
+			expanded, err := expandKeys(e)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = expanded
+		}
+	}
+
+	return v, nil
+}
+
+// keyIndex is a key written as an index, which the decoders give back
+// as whichever integer type fits it
+func keyIndex(k interface{}) (uint64, bool) {
+
+	switch i := k.(type) {
+	case uint64:
+		return i, true
+	case uint32:
+		return uint64(i), true
+	case uint16:
+		return uint64(i), true
+	case uint8:
+		return uint64(i), true
+	case int64:
+		return uint64(i), i >= 0
+	case int32:
+		return uint64(i), i >= 0
+	case int16:
+		return uint64(i), i >= 0
+	case int8:
+		return uint64(i), i >= 0
+	}
+
+	return 0, false
+}
+
+func fromCompactValue(v interface{}, pd *PageData) error {
+
+	expanded, err := expandKeys(v)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(expanded)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, pd)
+}
@@ -0,0 +1,279 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func codecTestPageData() PageData {
+	return PageData{
+		Exam: ExamDetails{
+			CourseCode: "ENGI12123",
+			Diet:       "2020-Summer",
+			UUID:       "69197384-fd15-42ac-ac16-82dbe4d52dd0",
+		},
+		Page: PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c", Number: 15, Of: 20},
+		Questions: []QuestionDetails{
+			QuestionDetails{
+				Name:           "Q1",
+				MarksAvailable: NewMark(7.5),
+				MarksAwarded:   NewMark(2.25),
+				Marking: []MarkingAction{
+					MarkingAction{Actor: "marker", Done: true, UnixTime: 1590000000123456789},
+				},
+			},
+		},
+		Processing: []ProcessingDetails{
+			ProcessingDetails{Name: "split", Sequence: 1, UnixTime: -1},
+		},
+		Revision: 3,
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+
+	pd := codecTestPageData()
+
+	var unknown PageData
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &unknown))
+
+	jsonToken, err := EncodeToken(&pd, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(jsonToken, "{"))
+
+	for _, codec := range []Codec{JSONCodec{}, JSONCodec{Canonical: true}, CBORCodec{}, MsgpackCodec{}} {
+
+		token, err := EncodeToken(&pd, codec)
+		assert.NoError(t, err)
+		assert.False(t, strings.ContainsAny(token, "<>"))
+		assert.True(t, wellFormed(token), codec.ID())
+
+		var out PageData
+		assert.NoError(t, DecodeToken(token, &out))
+		assert.Equal(t, pd, out, codec.ID())
+
+		if codec.ID() != "json" {
+			assert.True(t, strings.HasPrefix(token, codec.ID()+"~"))
+			assert.True(t, len(token) < len(jsonToken), "%s token is %d bytes, json %d", codec.ID(), len(token), len(jsonToken))
+		}
+
+		// unknown fields survive too
+		token, err = EncodeToken(&unknown, codec)
+		assert.NoError(t, err)
+
+		out = PageData{}
+		assert.NoError(t, DecodeToken(token, &out))
+		assert.Equal(t, UnknownFields(unknown), UnknownFields(out))
+	}
+}
+
+func TestCodecDeterministic(t *testing.T) {
+
+	pd := codecTestPageData()
+
+	for _, codec := range []Codec{CBORCodec{}, MsgpackCodec{}} {
+		a, err := EncodeToken(&pd, codec)
+		assert.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			b, err := EncodeToken(&pd, codec)
+			assert.NoError(t, err)
+			assert.Equal(t, a, b)
+		}
+	}
+}
+
+func TestMixedCodecTokens(t *testing.T) {
+
+	pd := codecTestPageData()
+
+	var text strings.Builder
+	for _, codec := range []Codec{JSONCodec{}, CBORCodec{}, MsgpackCodec{}} {
+		token, err := EncodeToken(&pd, codec)
+		assert.NoError(t, err)
+		text.WriteString(StartTag + EscapeToken(token) + EndTag + "\n")
+	}
+
+	tokens, err := ExtractPageDataChecked(text.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(tokens))
+
+	for _, token := range tokens {
+		out, err := DecodePageData(token, ReadOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, pd, out)
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+
+	var pd PageData
+
+	assert.Equal(t, ErrUnknownCodec, DecodeToken("zip:AAAA", &pd))
+	assert.Equal(t, ErrUnknownCodec, DecodeToken("no codec here", &pd))
+	assert.Error(t, DecodeToken("cbor:!!!", &pd))
+	assert.False(t, wellFormed("cbor:!!!"))
+	assert.Equal(t, ErrBadBase85, DecodeToken("cbor~abc\\", &pd))
+	assert.False(t, wellFormed("cbor~a"))
+	assert.False(t, wellFormed("{\"a\":"))
+
+	assert.Equal(t, ErrBadCodecID, RegisterCodec(badIDCodec{}))
+	assert.Equal(t, ErrBuiltInCodec, RegisterCodec(JSONCodec{Canonical: true}))
+	assert.Equal(t, ErrBuiltInCodec, RegisterCodec(CBORCodec{}))
+}
+
+func TestCodecLegacyTokens(t *testing.T) {
+
+	pd := codecTestPageData()
+
+	v, err := genericValue(&pd)
+	assert.NoError(t, err)
+
+	// every key in full, armoured in base64
+	data, err := cbor.Marshal(v)
+	assert.NoError(t, err)
+
+	var out PageData
+	assert.NoError(t, DecodeToken("cbor:"+base64.RawStdEncoding.EncodeToString(data), &out))
+	assert.Equal(t, pd, out)
+
+	var buf bytes.Buffer
+	assert.NoError(t, msgpack.NewEncoder(&buf).Encode(v))
+
+	out = PageData{}
+	assert.NoError(t, DecodeToken("msgpack:"+base64.RawStdEncoding.EncodeToString(buf.Bytes()), &out))
+	assert.Equal(t, pd, out)
+}
+
+func TestCompactKeysComplete(t *testing.T) {
+
+	seen := make(map[reflect.Type]bool)
+
+	var walk func(reflect.Type)
+	walk = func(typ reflect.Type) {
+
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+			typ = typ.Elem()
+		}
+
+		// types with their own JSON form, such as Mark, have no fields
+		marshaler := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+		if typ.Kind() != reflect.Struct || seen[typ] || reflect.PtrTo(typ).Implements(marshaler) {
+			return
+		}
+		seen[typ] = true
+
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			key := strings.Split(f.Tag.Get("json"), ",")[0]
+			if key == "-" {
+				continue
+			}
+			_, ok := compactKeyIndex[key]
+			assert.True(t, ok, "%s.%s: add %q to the end of compactKeys", typ.Name(), f.Name, key)
+			walk(f.Type)
+		}
+	}
+
+	walk(reflect.TypeOf(PageData{}))
+
+	assert.Equal(t, len(compactKeys), len(compactKeyIndex), "a key is listed twice")
+}
+
+func TestBase85(t *testing.T) {
+
+	for n := 0; n < 10; n++ {
+
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(0xff - i*37)
+		}
+
+		s := encodeBase85(data)
+		assert.Equal(t, (n*5+3)/4, len(s))
+		assert.False(t, strings.ContainsAny(s, "\\<> "), s)
+
+		out, err := decodeBase85(s)
+		assert.NoError(t, err)
+		assert.Equal(t, data, append([]byte{}, out...))
+	}
+
+	_, err := decodeBase85("#####")
+	assert.Equal(t, ErrBadBase85, err)
+}
+
+type badIDCodec struct{ JSONCodec }
+
+func (c badIDCodec) ID() string {
+	return "Bad:ID"
+}
+
+type namedCodec struct {
+	CBORCodec
+	id string
+}
+
+func (c namedCodec) ID() string {
+	return c.id
+}
+
+func TestRegisterCodecConcurrently(t *testing.T) {
+
+	token, err := EncodeToken(&PageData{Revision: 1}, CBORCodec{})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, RegisterCodec(namedCodec{id: fmt.Sprintf("test%d", i)}))
+		}(i)
+		go func() {
+			defer wg.Done()
+			var pd PageData
+			assert.NoError(t, DecodeToken(token, &pd))
+		}()
+	}
+
+	wg.Wait()
+
+	var pd PageData
+	assert.NoError(t, DecodeToken("test3~"+strings.TrimPrefix(token, "cbor~"), &pd))
+	assert.Equal(t, 1, pd.Revision)
+}
+
+func TestMarshalPageDataWithCodec(t *testing.T) {
+
+	pd := codecTestPageData()
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	assert.NoError(t, MarshalPageDataWithOptions(c, &pd, WriteOptions{Codec: CBORCodec{}}))
+	assert.NoError(t, MarshalPageDataWithOptions(c, &pd, WriteOptions{Codec: MsgpackCodec{}}))
+	assert.NoError(t, MarshalCanonicalPageData(c, &pd))
+
+	page := firstPageRoundTrip(t, c)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(pds)) {
+		for _, out := range pds {
+			assert.Equal(t, pd, out)
+		}
+	}
+}
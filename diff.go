@@ -0,0 +1,601 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diff compares two records field by field, in their JSON form, so the
+// paths are JSON pointers (RFC 6901) and unknown fields are compared too.
+// Lists are compared item by item, so a question, marking action or
+// processing step added at the end is a single addition. The changes can
+// be turned into a JSON Patch (RFC 6902) and replayed onto another copy
+// of the page with Apply.
+
+type ChangeKind int
+
+const (
+	Added    ChangeKind = iota
+	Removed  ChangeKind = iota
+	Modified ChangeKind = iota
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("change(%d)", int(k))
+}
+
+// Change is one difference. Old is empty for an addition, and New for
+// a removal.
+type Change struct {
+	Kind ChangeKind
+	Path string
+	Old  json.RawMessage
+	New  json.RawMessage
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("added %s: %s", c.Path, c.New)
+	case Removed:
+		return fmt.Sprintf("removed %s: %s", c.Path, c.Old)
+	}
+	return fmt.Sprintf("modified %s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+type Changes []Change
+
+// Under returns the changes at or below path, e.g. "/author" or
+// "/questions/0/markers"
+func (cs Changes) Under(path string) Changes {
+
+	var under Changes
+
+	for _, c := range cs {
+		if c.Path == path || strings.HasPrefix(c.Path, path+"/") {
+			under = append(under, c)
+		}
+	}
+
+	return under
+}
+
+// Patch is the JSON Patch that makes the changes
+func (cs Changes) Patch() Patch {
+
+	var patch Patch
+
+	for _, c := range cs {
+		switch c.Kind {
+		case Added:
+			patch = append(patch, PatchOperation{Op: "add", Path: c.Path, Value: c.New})
+		case Removed:
+			patch = append(patch, PatchOperation{Op: "remove", Path: c.Path})
+		case Modified:
+			patch = append(patch, PatchOperation{Op: "replace", Path: c.Path, Value: c.New})
+		}
+	}
+
+	return patch
+}
+
+// PatchOperation is one operation of an RFC 6902 JSON Patch
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch marshals to, and unmarshals from, the RFC 6902 JSON form
+type Patch []PatchOperation
+
+var (
+	ErrBadPointer  = errors.New("bad JSON pointer")
+	ErrPathMissing = errors.New("path does not exist")
+	ErrTestFailed  = errors.New("patch test failed")
+)
+
+// PatchError says which operation of a patch could not be applied
+type PatchError struct {
+	Index int
+	Op    PatchOperation
+	Err   error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("patch operation %d (%s %s): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// Diff lists the changes that turn a into b
+func Diff(a, b PageData) (Changes, error) {
+
+	va, err := toGeneric(a)
+	if err != nil {
+		return nil, err
+	}
+
+	vb, err := toGeneric(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes Changes
+
+	err = diffValues(va, vb, "", &changes)
+
+	return changes, err
+}
+
+// CreatePatch is the JSON Patch that turns a into b
+func CreatePatch(a, b PageData) (Patch, error) {
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes.Patch(), nil
+}
+
+// Apply returns a copy of pd with the patch applied. Either every
+// operation is applied, or none is, and a *PatchError says which failed.
+func Apply(pd PageData, patch Patch) (PageData, error) {
+
+	doc, err := toGeneric(pd)
+	if err != nil {
+		return PageData{}, err
+	}
+
+	for i, op := range patch {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return PageData{}, &PatchError{Index: i, Op: op, Err: err}
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return PageData{}, err
+	}
+
+	var out PageData
+
+	err = json.Unmarshal(data, &out)
+
+	return out, err
+}
+
+// toGeneric is v as maps, slices and scalars, keeping numbers exact
+func toGeneric(v interface{}) (interface{}, error) {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeGeneric(data)
+}
+
+func decodeGeneric(data []byte) (interface{}, error) {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+
+	return v, err
+}
+
+func diffValues(a, b interface{}, path string, changes *Changes) error {
+
+	ma, aIsMap := a.(map[string]interface{})
+	mb, bIsMap := b.(map[string]interface{})
+
+	if aIsMap && bIsMap {
+
+		var keys []string
+		for k := range ma {
+			keys = append(keys, k)
+		}
+		for k := range mb {
+			if _, ok := ma[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+
+			p := path + "/" + escapePointer(k)
+			va, inA := ma[k]
+			vb, inB := mb[k]
+
+			switch {
+			case inA && !inB:
+				if err := addChange(changes, Removed, p, va, nil); err != nil {
+					return err
+				}
+			case !inA && inB:
+				if err := addChange(changes, Added, p, nil, vb); err != nil {
+					return err
+				}
+			default:
+				if err := diffValues(va, vb, p, changes); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	sa, aIsSlice := a.([]interface{})
+	sb, bIsSlice := b.([]interface{})
+
+	if aIsSlice && bIsSlice {
+
+		n := len(sa)
+		if len(sb) < n {
+			n = len(sb)
+		}
+
+		for i := 0; i < n; i++ {
+			if err := diffValues(sa[i], sb[i], fmt.Sprintf("%s/%d", path, i), changes); err != nil {
+				return err
+			}
+		}
+
+		for i := n; i < len(sb); i++ {
+			if err := addChange(changes, Added, fmt.Sprintf("%s/%d", path, i), nil, sb[i]); err != nil {
+				return err
+			}
+		}
+
+		// from the end, so each index is still valid when the patch is applied
+		for i := len(sa) - 1; i >= n; i-- {
+			if err := addChange(changes, Removed, fmt.Sprintf("%s/%d", path, i), sa[i], nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	equal, err := genericEqual(a, b)
+	if err != nil {
+		return err
+	}
+
+	if !equal {
+		return addChange(changes, Modified, path, a, b)
+	}
+
+	return nil
+}
+
+func addChange(changes *Changes, kind ChangeKind, path string, before, after interface{}) error {
+
+	c := Change{Kind: kind, Path: path}
+
+	if kind != Added {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		c.Old = data
+	}
+
+	if kind != Removed {
+		data, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		c.New = data
+	}
+
+	*changes = append(*changes, c)
+
+	return nil
+}
+
+// genericEqual compares two values by their canonical JSON
+func genericEqual(a, b interface{}) (bool, error) {
+
+	da, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+
+	db, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+
+	ca, err := CanonicalJSON(da)
+	if err != nil {
+		return false, err
+	}
+
+	cb, err := CanonicalJSON(db)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(ca, cb), nil
+}
+
+// parsePointer splits a JSON pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrBadPointer
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex parses an array index, which may be one past the end
+// (or "-") only when adding
+func arrayIndex(token string, length int, adding bool) (int, error) {
+
+	if adding && token == "-" {
+		return length, nil
+	}
+
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrBadPointer
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return 0, ErrBadPointer
+	}
+
+	max := length - 1
+	if adding {
+		max = length
+	}
+
+	if i > max {
+		return 0, ErrPathMissing
+	}
+
+	return i, nil
+}
+
+func getPointer(doc interface{}, pointer string) (interface{}, error) {
+
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	node := doc
+
+	for _, t := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[t]
+			if !ok {
+				return nil, ErrPathMissing
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(t, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, ErrPathMissing
+		}
+	}
+
+	return node, nil
+}
+
+// modifyPointer calls f on the parent of the pointer's target and the
+// last token, and puts whatever f returns back in place of the parent.
+// Returns the new document.
+func modifyPointer(doc interface{}, tokens []string, f func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+
+	if len(tokens) == 1 {
+		return f(doc, tokens[0])
+	}
+
+	switch n := doc.(type) {
+
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, ErrPathMissing
+		}
+		updated, err := modifyPointer(child, tokens[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = updated
+		return n, nil
+
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := modifyPointer(n[i], tokens[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	}
+
+	return nil, ErrPathMissing
+}
+
+func addPointer(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return modifyPointer(doc, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), true)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		}
+		return nil, ErrPathMissing
+	})
+}
+
+func removePointer(doc interface{}, pointer string) (interface{}, error) {
+
+	tokens, err := parsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	return modifyPointer(doc, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, ErrPathMissing
+			}
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(p), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		}
+		return nil, ErrPathMissing
+	})
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+
+	var value interface{}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New("missing value")
+		}
+		v, err := decodeGeneric(op.Value)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	}
+
+	switch op.Op {
+
+	case "add":
+		return addPointer(doc, op.Path, value)
+
+	case "remove":
+		return removePointer(doc, op.Path)
+
+	case "replace":
+		if _, err := getPointer(doc, op.Path); err != nil {
+			return nil, err
+		}
+		doc, err := removePointer(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(doc, op.Path, value)
+
+	case "move":
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		v, err := getPointer(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := removePointer(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(doc, op.Path, v)
+
+	case "copy":
+		v, err := getPointer(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		// copy, so later operations on one don't change the other
+		v, err = toGeneric(v)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(doc, op.Path, v)
+
+	case "test":
+		v, err := getPointer(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		equal, err := genericEqual(v, value)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			return nil, ErrTestFailed
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
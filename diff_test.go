@@ -0,0 +1,140 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func diffTestPair() (PageData, PageData) {
+
+	before := codecTestPageData()
+	before.Author = AuthorDetails{Anonymous: "B12345"}
+	before.Questions = append(before.Questions, QuestionDetails{Name: "Q2", MarksAvailable: NewMark(5)})
+
+	after := copyPageData(before)
+	after.Revision = 4
+	after.Questions[1].MarksAwarded = NewMark(3)
+	after.Questions[1].Marking = []MarkingAction{MarkingAction{Actor: "marker", Done: true}}
+	after.Processing = append(after.Processing, ProcessingDetails{Name: "mark", Sequence: 2})
+
+	return before, after
+}
+
+func TestDiff(t *testing.T) {
+
+	before, after := diffTestPair()
+
+	changes, err := Diff(before, after)
+	assert.NoError(t, err)
+
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Kind.String()+" "+c.Path)
+	}
+
+	assert.Equal(t, []string{
+		"added /processing/1",
+		"modified /questions/1/markers",
+		"modified /questions/1/marksAwarded",
+		"modified /revision",
+	}, paths)
+
+	assert.Empty(t, changes.Under("/author"))
+	assert.Equal(t, 2, len(changes.Under("/questions/1")))
+	assert.Equal(t, `modified /revision: 3 -> 4`, changes.Under("/revision")[0].String())
+
+	// nothing changed
+	changes, err = Diff(before, before)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// removal, and unknown fields are compared too
+	var unknown PageData
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &unknown))
+	trimmed := copyPageData(unknown)
+	delete(trimmed.Exam.Extra, "term")
+	trimmed.Questions = nil
+
+	changes, err = Diff(unknown, trimmed)
+	assert.NoError(t, err)
+	assert.Equal(t, Removed, changes.Under("/exam/term")[0].Kind)
+	assert.Equal(t, `"S1"`, string(changes.Under("/exam/term")[0].Old))
+}
+
+func TestPatchApply(t *testing.T) {
+
+	before, after := diffTestPair()
+
+	patch, err := CreatePatch(before, after)
+	assert.NoError(t, err)
+
+	out, err := Apply(before, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, after, out)
+
+	// and back again, which removes the processing step
+	patch, err = CreatePatch(after, before)
+	assert.NoError(t, err)
+	out, err = Apply(after, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, before, out)
+
+	// patches survive being sent as JSON
+	data, err := json.Marshal(patch)
+	assert.NoError(t, err)
+	var decoded Patch
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	out, err = Apply(after, decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, before, out)
+}
+
+func TestApplyRFC6902(t *testing.T) {
+
+	pd := PageData{Custom: []CustomDetails{
+		CustomDetails{Key: "a", Value: "1"},
+		CustomDetails{Key: "b", Value: "2"},
+	}}
+
+	var patch Patch
+	assert.NoError(t, json.Unmarshal([]byte(`[
+	  {"op": "test", "path": "/custom/0/name", "value": "a"},
+	  {"op": "add", "path": "/custom/1", "value": {"name": "c", "value": "3"}},
+	  {"op": "add", "path": "/custom/-", "value": {"name": "d", "value": "4"}},
+	  {"op": "remove", "path": "/custom/0"},
+	  {"op": "move", "from": "/custom/0/value", "path": "/todo"},
+	  {"op": "copy", "from": "/todo", "path": "/preparedfor"},
+	  {"op": "replace", "path": "/exam/diet", "value": "2020-Summer"},
+	  {"op": "add", "path": "/exam/x~1y", "value": true}
+	]`), &patch))
+
+	out, err := Apply(pd, patch)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"c", "b", "d"}, []string{out.Custom[0].Key, out.Custom[1].Key, out.Custom[2].Key})
+	assert.Equal(t, "", out.Custom[0].Value)
+	assert.Equal(t, "3", out.ToDo)
+	assert.Equal(t, "3", out.PreparedFor)
+	assert.Equal(t, "2020-Summer", out.Exam.Diet)
+	assert.Equal(t, json.RawMessage("true"), out.Exam.Extra["x/y"])
+
+	for _, bad := range []string{
+		`[{"op": "test", "path": "/custom/0/name", "value": "z"}]`,
+		`[{"op": "remove", "path": "/custom/2"}]`,
+		`[{"op": "replace", "path": "/nothere", "value": 1}]`,
+		`[{"op": "add", "path": "/custom/01", "value": 1}]`,
+		`[{"op": "add", "path": "custom", "value": 1}]`,
+		`[{"op": "add", "path": "/custom/0"}]`,
+		`[{"op": "move", "from": "/custom", "path": "/custom/0"}]`,
+		`[{"op": "frobnicate", "path": "/custom"}]`,
+	} {
+		var patch Patch
+		assert.NoError(t, json.Unmarshal([]byte(bad), &patch))
+		_, err := Apply(pd, patch)
+		if assert.IsType(t, &PatchError{}, err, bad) {
+			assert.Equal(t, 0, err.(*PatchError).Index)
+		}
+	}
+}
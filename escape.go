@@ -0,0 +1,114 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Anything that ends up inside a token, such as a marker's comment or a
+// student's filename, could contain a tag and split the token in the
+// wrong place, or even smuggle in a forged record, e.g. a filename of
+//   x</gradex-pagedata><gradex-pagedata>{...forged...}</gradex-pagedata><gradex-pagedata>
+// So the writers escape < and > the same way JSON does, and escape \ too,
+// so that text which already looks like an escape comes back as it was.
+// The readers undo this before returning a token. Records from before \
+// was escaped read the same, unless they held the text \u003c or \u003e.
+
+var tokenEscaper = strings.NewReplacer(`\`, `\u005c`, "<", `\u003c`, ">", `\u003e`)
+
+var tokenUnescaper = strings.NewReplacer(`\u005c`, `\`, `\u003c`, "<", `\u003e`, ">")
+
+var ErrSuspectToken = errors.New("suspect page data token, possible tag injection")
+
+// SuspectTokenError lists tokens that were not used because they
+// look like the result of tag injection
+type SuspectTokenError struct {
+	Tokens []string
+}
+
+func (e *SuspectTokenError) Error() string {
+	return fmt.Sprintf("%d suspect page data token(s) ignored, possible tag injection", len(e.Tokens))
+}
+
+// EscapeToken makes text safe to put between tags
+func EscapeToken(text string) string {
+	return tokenEscaper.Replace(text)
+}
+
+// UnescapeToken gives back the text that EscapeToken was given
+func UnescapeToken(text string) string {
+	return tokenUnescaper.Replace(text)
+}
+
+func unescapeTokens(raw []string) []string {
+
+	var tokens []string
+
+	for _, token := range raw {
+		tokens = append(tokens, UnescapeToken(token))
+	}
+
+	return tokens
+}
+
+// ExtractPageDataChecked returns the page data tokens that are safe to use,
+// and a *SuspectTokenError if any were held back. As well as the checks
+// made by CheckPageData, a token is suspect if it has a raw < or > between
+// its tags, which our writers never emit.
+func ExtractPageDataChecked(pageText string) ([]string, error) {
+
+	raw := extractTokens(pageText, DefaultNamespace)
+	tokens := unescapeTokens(raw)
+
+	good, suspect := splitSuspect(tokens, func(i int) bool {
+		return strings.ContainsAny(raw[i], "<>") || !wellFormed(tokens[i])
+	})
+
+	if len(suspect) > 0 {
+		return good, &SuspectTokenError{Tokens: suspect}
+	}
+
+	return good, nil
+}
+
+// CheckPageData splits tokens, in page order, into those that are
+// safe to use and those that are not. A token is suspect if it is not
+// well formed, or lies between two tokens that are not well formed
+// (a record injected by a writer that didn't escape splits its host
+// into a fragment either side of it). This only catches splices that
+// leave a broken fragment; it is escaping on write that keeps tags out
+// of tokens, so it can't stop a forged record that is written whole.
+func CheckPageData(tokens []string) (good []string, suspect []string) {
+	return splitSuspect(tokens, func(i int) bool {
+		return !wellFormed(tokens[i])
+	})
+}
+
+// splitSuspect holds back the broken tokens, and any between them
+func splitSuspect(tokens []string, isBroken func(i int) bool) (good []string, suspect []string) {
+
+	broken := make([]bool, len(tokens))
+	first := -1
+	last := -1
+
+	for i := range tokens {
+		if isBroken(i) {
+			broken[i] = true
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	for i, token := range tokens {
+		if broken[i] || (i > first && i < last) {
+			suspect = append(suspect, token)
+			continue
+		}
+		good = append(good, token)
+	}
+
+	return good, suspect
+}
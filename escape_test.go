@@ -0,0 +1,119 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+)
+
+const forged = `{"exam":{"courseCode":"FORGED"},"revision":99}`
+
+func TestEscapeToken(t *testing.T) {
+
+	text := `{"comment":"` + EndTag + StartTag + `"}`
+
+	escaped := EscapeToken(text)
+	assert.NotContains(t, escaped, "<")
+	assert.NotContains(t, escaped, ">")
+	assert.Equal(t, text, UnescapeToken(escaped))
+
+	var v map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(UnescapeToken(escaped)), &v))
+	assert.Equal(t, EndTag+StartTag, v["comment"])
+
+	// text that already looks escaped comes back as it was
+	for _, text := range []string{`\u003c`, `\\u003e<`, `\`, `\u005c`, `a\"b`} {
+		assert.Equal(t, text, UnescapeToken(EscapeToken(text)), text)
+	}
+
+	// a JSON token's own escapes survive
+	token := `{"comment":"\u003c \\u003c \" \\"}`
+	assert.NoError(t, json.Unmarshal([]byte(UnescapeToken(EscapeToken(token))), &v))
+	assert.Equal(t, `< \u003c " \`, v["comment"])
+}
+
+func TestExtractUnescapes(t *testing.T) {
+
+	payload := `if a<b then print "\u003c"`
+	text := "<plagiarism-check>" + EscapeToken(payload) + "</plagiarism-check>"
+
+	assert.Equal(t, []string{payload}, ExtractNamespaceData(text, "plagiarism-check"))
+	assert.Equal(t, map[string][]string{"plagiarism-check": []string{payload}}, ExtractAllNamespaceData(text))
+
+	good, err := ExtractPageDataChecked(StartTag + EscapeToken(`{"a":"<\u003c>"}`) + EndTag)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"a":"<\u003c>"}`}, good)
+}
+
+func TestCheckPageDataInjection(t *testing.T) {
+
+	// what an unescaped writer would have produced for a hostile filename
+	filename := "x" + EndTag + StartTag + forged + EndTag + StartTag + "y"
+	host := `{"submission":{"originalFilename":"` + filename + `"},"revision":1}`
+	honest := `{"revision":0}`
+
+	text := StartTag + honest + EndTag + "\n" + StartTag + host + EndTag + "\n" + StartTag + honest + EndTag
+
+	tokens := ExtractPageData(text)
+	assert.Equal(t, 5, len(tokens))
+	assert.Contains(t, tokens, forged)
+
+	good, err := ExtractPageDataChecked(text)
+	assert.Equal(t, []string{honest, honest}, good)
+	if assert.Error(t, err) {
+		suspect, ok := err.(*SuspectTokenError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 3, len(suspect.Tokens))
+			assert.Contains(t, suspect.Tokens, forged)
+		}
+	}
+}
+
+func TestCheckPageDataNested(t *testing.T) {
+
+	// unterminated start tag swallows the next token
+	text := StartTag + `{"revision":0` + StartTag + forged + EndTag
+
+	good, err := ExtractPageDataChecked(text)
+	assert.Equal(t, 0, len(good))
+	assert.Error(t, err)
+
+	good, err = ExtractPageDataChecked(StartTag + forged + EndTag)
+	assert.Equal(t, []string{forged}, good)
+	assert.NoError(t, err)
+}
+
+func TestMarshalHostileFilename(t *testing.T) {
+
+	filename := "x" + EndTag + StartTag + forged + EndTag + StartTag + "y"
+
+	pd := PageData{
+		Submission: SubmissionDetails{OriginalFilename: filename},
+		Questions: []QuestionDetails{
+			QuestionDetails{
+				Marking: []MarkingAction{
+					MarkingAction{Custom: CustomDetails{Key: "note", Value: EndTag}},
+				},
+			},
+		},
+	}
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+	assert.NoError(t, MarshalPageData(c, &pd))
+	WritePageData(c, `{"custom":[{"name":"raw","value":"`+EndTag+`"}]}`)
+
+	page := firstPageRoundTrip(t, c)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(pds)) {
+		for _, pd := range pds {
+			assert.NotEqual(t, 99, pd.Revision)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package pdfpagedata
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/timdrysdale/unipdf/v3/creator"
+)
+
+const (
+	footerFontSize = 8
+	footerMargin   = 12
+)
+
+// MarshalPageDataWithFooter writes the hidden page data and also
+// a visible footer, so the page can still be identified if the
+// hidden text is lost in print-and-scan
+func MarshalPageDataWithFooter(c *creator.Creator, pd *PageData) error {
+
+	err := MarshalPageData(c, pd)
+	if err != nil {
+		return err
+	}
+
+	return WritePageFooter(c, pd)
+}
+
+// WritePageFooter draws course code, page n of m and the short code
+// along the bottom of the current page
+func WritePageFooter(c *creator.Creator, pd *PageData) error {
+
+	if pd.Page.UUID == "" {
+		return ErrNoPageUUID
+	}
+
+	p := c.NewParagraph(FooterText(pd))
+	p.SetFontSize(footerFontSize)
+	p.SetEnableWrap(false)
+	p.SetColor(creator.ColorRGBFrom8bit(96, 96, 96))
+	p.SetPos(footerMargin, c.Height()-footerMargin-footerFontSize)
+
+	return c.Draw(p)
+}
+
+// FooterText is the human readable identity of the page
+func FooterText(pd *PageData) string {
+
+	var parts []string
+
+	if pd.Exam.CourseCode != "" {
+		parts = append(parts, pd.Exam.CourseCode)
+	}
+
+	switch {
+	case pd.Page.Number > 0 && pd.Page.Of > 0:
+		parts = append(parts, fmt.Sprintf("page %d of %d", pd.Page.Number, pd.Page.Of))
+	case pd.Page.Number > 0:
+		parts = append(parts, fmt.Sprintf("page %d", pd.Page.Number))
+	}
+
+	parts = append(parts, ShortCode(pd.Page.UUID))
+
+	return strings.Join(parts, "   ")
+}
@@ -0,0 +1,48 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	"github.com/timdrysdale/unipdf/v3/extractor"
+)
+
+func TestWritePageFooter(t *testing.T) {
+
+	pd := PageData{
+		Exam: ExamDetails{CourseCode: "ENGI12123"},
+		Page: PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c", Number: 2, Of: 5},
+	}
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	assert.NoError(t, MarshalPageDataWithFooter(c, &pd))
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Write(&buf))
+
+	page, err := readerForTest(t, buf.Bytes()).GetPage(1)
+	assert.NoError(t, err)
+
+	ex, err := extractor.New(page)
+	assert.NoError(t, err)
+
+	text, err := ex.ExtractText()
+	assert.NoError(t, err)
+	assert.Contains(t, text, "ENGI12123   page 2 of 5   "+ShortCode(pd.Page.UUID))
+
+	// and the hidden page data is still there
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(pds)) {
+		assert.Equal(t, pd.Page.UUID, pds[0].Page.UUID)
+	}
+
+	// a footer needs a page UUID for its short code
+	assert.Equal(t, ErrNoPageUUID, WritePageFooter(c, &PageData{}))
+}
@@ -0,0 +1,66 @@
+package pdfpagedata
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzExtractPageData(f *testing.F) {
+
+	f.Add(StartTag + `{"revision":1}` + EndTag)
+	f.Add(EndTag + StartTag + StartTag + EndTag + EndTag)
+	f.Add(StartTag + `{"a":"x` + EndTag + StartTag + `{}` + EndTag + StartTag + `y"}` + EndTag)
+	f.Add("<a><b></a></b><gradex-pagedata>")
+
+	f.Fuzz(func(t *testing.T, text string) {
+
+		for _, token := range extractTokens(text, DefaultNamespace) {
+			if strings.Contains(token, EndTag) {
+				t.Errorf("token contains end tag: %q", token)
+			}
+		}
+
+		if UnescapeToken(EscapeToken(text)) != text {
+			t.Errorf("escaping is not undone: %q", text)
+		}
+
+		if strings.ContainsAny(EscapeToken(text), "<>") {
+			t.Errorf("escaped text contains tag characters: %q", text)
+		}
+
+		good, suspect := CheckPageData(ExtractPageData(text))
+		if len(good)+len(suspect) != len(ExtractPageData(text)) {
+			t.Error("tokens lost by check")
+		}
+
+		for namespace := range ExtractAllNamespaceData(text) {
+			if !ValidNamespace(namespace) {
+				t.Errorf("invalid namespace %q", namespace)
+			}
+		}
+	})
+}
+
+func FuzzDecodePageData(f *testing.F) {
+
+	f.Add(`{"revision":1}`)
+	f.Add(`{"questions":[{"parts":[{"parts":[{"parts":[]}]}]}]}`)
+	f.Add(`{"exam":{"courseCode":"</gradex-pagedata>"}}`)
+	f.Add(`[[[[[[[[[[[[[[[[[[[[`)
+
+	limits := Limits{MaxTokenSize: 1 << 16, MaxDepth: 4}
+
+	f.Fuzz(func(t *testing.T, token string) {
+
+		pd, err := DecodePageData(token, ReadOptions{Limits: &limits})
+		if err != nil {
+			return
+		}
+
+		for _, q := range pd.Questions {
+			if partsDepth(q) > limits.MaxDepth {
+				t.Error("parts depth limit not enforced")
+			}
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"sort"
+)
+
+// History is every record found for one page, oldest first, instead of
+// just the latest that PruneOldRevisions keeps. Records are ordered by
+// Revision, then by the time of their last processing step (its HLC if
+// every record has one, else UnixTime), then by their canonical hash,
+// so two records with the same revision always come out in the same
+// order, whichever order they were read in.
+type History []PageData
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// NewHistory orders the records for one page. pds is not changed.
+func NewHistory(pds []PageData) History {
+
+	h := make(History, len(pds))
+	copy(h, pds)
+
+	keys := make([]historyKey, len(h))
+	for i, pd := range h {
+		keys[i] = newHistoryKey(pd)
+	}
+
+	useHLC := allStamped(len(keys), func(i int) *HLC { return keys[i].hlc })
+
+	sort.Sort(historySorter{h, keys, useHLC})
+
+	return h
+}
+
+// Histories gives the history of each page, keyed the same way as
+// GetPageDataFromFile's results
+func Histories(pdm map[int][]PageData) map[int]History {
+
+	hm := make(map[int]History, len(pdm))
+
+	for k, v := range pdm {
+		hm[k] = NewHistory(v)
+	}
+
+	return hm
+}
+
+// Latest is the record that SelectPageDataByRevision would choose
+func (h History) Latest() (PageData, error) {
+
+	if len(h) < 1 {
+		return PageData{}, errors.New("empty")
+	}
+
+	return h[len(h)-1], nil
+}
+
+// Revision returns revision n. If there is more than one record with
+// that revision, it is the one that sorts last.
+func (h History) Revision(n int) (PageData, error) {
+
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].Revision == n {
+			return h[i], nil
+		}
+	}
+
+	return PageData{}, ErrRevisionNotFound
+}
+
+// All returns every record with revision n, in order
+func (h History) All(n int) []PageData {
+
+	var pds []PageData
+
+	for _, pd := range h {
+		if pd.Revision == n {
+			pds = append(pds, pd)
+		}
+	}
+
+	return pds
+}
+
+// Revisions lists the revision numbers present, in order, without repeats
+func (h History) Revisions() []int {
+
+	var revs []int
+
+	for _, pd := range h {
+		if len(revs) == 0 || revs[len(revs)-1] != pd.Revision {
+			revs = append(revs, pd.Revision)
+		}
+	}
+
+	return revs
+}
+
+// Between returns the records made after revision from, up to and
+// including revision to, i.e. the changes that took from to to
+func (h History) Between(from, to int) []PageData {
+
+	var pds []PageData
+
+	for _, pd := range h {
+		if pd.Revision > from && pd.Revision <= to {
+			pds = append(pds, pd)
+		}
+	}
+
+	return pds
+}
+
+type historyKey struct {
+	revision int
+	hlc      *HLC
+	lastTime int64
+	hash     string
+}
+
+func newHistoryKey(pd PageData) historyKey {
+
+	key := historyKey{revision: pd.Revision}
+
+	if last, err := SelectProcessByLast(pd); err == nil {
+		key.hlc = last.HLC
+		key.lastTime = last.UnixTime
+	}
+
+	// an unhashable record sorts first among its equals
+	key.hash, _ = pd.Hash()
+
+	return key
+}
+
+func (k historyKey) less(o historyKey, useHLC bool) bool {
+
+	if k.revision != o.revision {
+		return k.revision < o.revision
+	}
+
+	if useHLC {
+		if c := k.hlc.Compare(*o.hlc); c != 0 {
+			return c < 0
+		}
+	}
+
+	if k.lastTime != o.lastTime {
+		return k.lastTime < o.lastTime
+	}
+
+	return k.hash < o.hash
+}
+
+type historySorter struct {
+	h      History
+	keys   []historyKey
+	useHLC bool
+}
+
+func (s historySorter) Len() int {
+	return len(s.h)
+}
+
+func (s historySorter) Less(i, j int) bool {
+	return s.keys[i].less(s.keys[j], s.useHLC)
+}
+
+func (s historySorter) Swap(i, j int) {
+	s.h[i], s.h[j] = s.h[j], s.h[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
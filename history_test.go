@@ -0,0 +1,102 @@
+package pdfpagedata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func historyTestSet() []PageData {
+
+	return []PageData{
+		PageData{Revision: 2, ToDo: "b", Processing: []ProcessingDetails{
+			ProcessingDetails{Sequence: 1, UnixTime: 100},
+			ProcessingDetails{Sequence: 2, UnixTime: 300},
+		}},
+		PageData{Revision: 0, ToDo: "first"},
+		PageData{Revision: 2, ToDo: "a", Processing: []ProcessingDetails{
+			ProcessingDetails{Sequence: 1, UnixTime: 200},
+		}},
+		PageData{Revision: 1, ToDo: "second"},
+		PageData{Revision: 3, ToDo: "y"},
+		PageData{Revision: 3, ToDo: "x"},
+	}
+}
+
+func todos(pds []PageData) []string {
+	var s []string
+	for _, pd := range pds {
+		s = append(s, pd.ToDo)
+	}
+	return s
+}
+
+func TestHistoryOrder(t *testing.T) {
+
+	pds := historyTestSet()
+
+	h := NewHistory(pds)
+
+	// same revision and time, so by hash
+	hx, _ := PageData{Revision: 3, ToDo: "x"}.Hash()
+	hy, _ := PageData{Revision: 3, ToDo: "y"}.Hash()
+	last := []string{"x", "y"}
+	if hy < hx {
+		last = []string{"y", "x"}
+	}
+
+	assert.Equal(t, append([]string{"first", "second", "a", "b"}, last...), todos(h))
+	assert.Equal(t, "b", pds[0].ToDo, "input unchanged")
+
+	// order doesn't depend on the input order
+	for i := 0; i < len(pds); i++ {
+		rotated := append(append([]PageData{}, pds[i:]...), pds[:i]...)
+		assert.Equal(t, todos(h), todos(NewHistory(rotated)))
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3}, h.Revisions())
+}
+
+func TestHistoryRevision(t *testing.T) {
+
+	h := NewHistory(historyTestSet())
+
+	pd, err := h.Revision(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", pd.ToDo)
+
+	assert.Equal(t, []string{"a", "b"}, todos(h.All(2)))
+
+	_, err = h.Revision(7)
+	assert.Equal(t, ErrRevisionNotFound, err)
+
+	assert.Equal(t, []string{"second", "a", "b"}, todos(h.Between(0, 2)))
+	assert.Empty(t, h.Between(3, 3))
+
+	latest, err := h.Latest()
+	assert.NoError(t, err)
+	selected, err := SelectPageDataByRevision(historyTestSet())
+	assert.NoError(t, err)
+	assert.Equal(t, latest, selected)
+
+	_, err = History{}.Latest()
+	assert.Error(t, err)
+}
+
+func TestHistories(t *testing.T) {
+
+	pdm := map[int][]PageData{
+		1: historyTestSet(),
+		2: []PageData{PageData{Revision: 4}},
+	}
+
+	hm := Histories(pdm)
+
+	assert.Equal(t, 6, len(hm[1]))
+	assert.Equal(t, []int{4}, hm[2].Revisions())
+
+	// the destructive version agrees
+	assert.NoError(t, PruneOldRevisions(&pdm))
+	latest, _ := hm[1].Latest()
+	assert.Equal(t, latest, pdm[1][0])
+}
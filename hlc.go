@@ -0,0 +1,179 @@
+package pdfpagedata
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// UnixTime comes from whichever laptop did the work, so with skewed
+// clocks a later step can look older than the one before it. A hybrid
+// logical clock (Kulkarni et al. 2014) never goes backwards past anything
+// it has seen: each tool keeps a Clock, lets it Observe every record it
+// reads, and Stamps every step it writes, so a step is always stamped
+// after the steps it was built on, whatever the wall clocks say.
+
+// Compare returns -1, 0 or +1 as h is before, the same as, or after o
+func (h HLC) Compare(o HLC) int {
+
+	switch {
+	case h.WallTime < o.WallTime:
+		return -1
+	case h.WallTime > o.WallTime:
+		return 1
+	case h.Logical < o.Logical:
+		return -1
+	case h.Logical > o.Logical:
+		return 1
+	}
+
+	return strings.Compare(h.Node, o.Node)
+}
+
+// Clock issues HLC timestamps for one node (tool, or machine). It is
+// safe to use from more than one goroutine.
+type Clock struct {
+	node string
+	now  func() time.Time
+	mu   sync.Mutex
+	last HLC
+}
+
+// NewClock makes a clock for the node; if node is empty, a random
+// one is used
+func NewClock(node string) *Clock {
+
+	if node == "" {
+		node = newUUID()
+	}
+
+	return &Clock{node: node, now: time.Now}
+}
+
+// WithWallClock sets where the clock gets the physical time from,
+// e.g. for tests
+func (c *Clock) WithWallClock(now func() time.Time) *Clock {
+	c.now = now
+	return c
+}
+
+// Stamp returns a new timestamp, later than any issued or observed so
+// far, for something being written now
+func (c *Clock) Stamp() *HLC {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pt := c.now().UnixNano()
+
+	if pt > c.last.WallTime {
+		c.last = HLC{WallTime: pt}
+	} else {
+		c.last.Logical++
+	}
+
+	return &HLC{WallTime: c.last.WallTime, Logical: c.last.Logical, Node: c.node}
+}
+
+// Update moves the clock past a timestamp from elsewhere
+func (c *Clock) Update(remote HLC) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pt := c.now().UnixNano()
+
+	wall := c.last.WallTime
+	if remote.WallTime > wall {
+		wall = remote.WallTime
+	}
+	if pt > wall {
+		wall = pt
+	}
+
+	switch {
+	case wall == c.last.WallTime && wall == remote.WallTime:
+		if remote.Logical > c.last.Logical {
+			c.last.Logical = remote.Logical
+		}
+		c.last.Logical++
+	case wall == c.last.WallTime:
+		c.last.Logical++
+	case wall == remote.WallTime:
+		c.last = HLC{WallTime: wall, Logical: remote.Logical + 1}
+	default:
+		c.last = HLC{WallTime: wall}
+	}
+}
+
+// Observe moves the clock past every timestamp in the record, so
+// whatever is stamped next comes after it
+func (c *Clock) Observe(pd PageData) {
+
+	for _, q := range pd.Questions {
+		c.observeQuestion(q)
+	}
+
+	for _, p := range pd.Processing {
+		if p.HLC != nil {
+			c.Update(*p.HLC)
+		}
+	}
+}
+
+func (c *Clock) observeQuestion(q QuestionDetails) {
+
+	if q.HLC != nil {
+		c.Update(*q.HLC)
+	}
+
+	for _, part := range q.Parts {
+		c.observeQuestion(part)
+	}
+
+	for _, actions := range [][]MarkingAction{q.Marking, q.Moderating, q.Checking} {
+		for _, a := range actions {
+			if a.HLC != nil {
+				c.Update(*a.HLC)
+			}
+		}
+	}
+}
+
+// StampMarking stamps a marking action about to be added to a record
+func (c *Clock) StampMarking(action *MarkingAction) {
+	action.HLC = c.Stamp()
+	action.UnixTime = action.HLC.WallTime
+}
+
+// compareLegacy orders by Sequence, then UnixTime, as the selectors
+// always have
+func compareLegacy(seqA, seqB int, timeA, timeB int64) int {
+
+	switch {
+	case seqA < seqB:
+		return -1
+	case seqA > seqB:
+		return 1
+	case timeA < timeB:
+		return -1
+	case timeA > timeB:
+		return 1
+	}
+
+	return 0
+}
+
+// allStamped reports whether every item has an HLC. Timestamps are only
+// used to order a set of items if they all have one, else an old record
+// with none would sort inconsistently against the rest.
+func allStamped(n int, hlc func(i int) *HLC) bool {
+
+	for i := 0; i < n; i++ {
+		if hlc(i) == nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,123 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedClock returns a wall clock stuck at t, that can be moved
+func fixedClock(t *int64) func() time.Time {
+	return func() time.Time {
+		return time.Unix(0, *t)
+	}
+}
+
+func TestClockStamp(t *testing.T) {
+
+	wall := int64(1000)
+	c := NewClock("a").WithWallClock(fixedClock(&wall))
+
+	h1 := c.Stamp()
+	h2 := c.Stamp()
+	assert.Equal(t, HLC{WallTime: 1000, Node: "a"}, *h1)
+	assert.Equal(t, HLC{WallTime: 1000, Logical: 1, Node: "a"}, *h2)
+
+	// wall clock goes backwards, HLC doesn't
+	wall = 500
+	h3 := c.Stamp()
+	assert.Equal(t, 1, h3.Compare(*h2))
+
+	wall = 2000
+	assert.Equal(t, HLC{WallTime: 2000, Node: "a"}, *c.Stamp())
+
+	assert.NotEqual(t, "", NewClock("").node)
+}
+
+func TestClockSkew(t *testing.T) {
+
+	// b's laptop is an hour slow
+	wallA := int64(time.Hour)
+	wallB := int64(0)
+	a := NewClock("a").WithWallClock(fixedClock(&wallA))
+	b := NewClock("b").WithWallClock(fixedClock(&wallB))
+
+	split := NewBuilder().WithClock(fixedClock(&wallA)).WithHLC(a).Process("split", ContactDetails{}).Build()
+
+	// b marks it later, but by b's wall clock earlier
+	wallB += int64(time.Minute)
+	marked := UpdateBuilder(split).WithClock(fixedClock(&wallB)).WithHLC(b).Process("mark", ContactDetails{}).Build()
+
+	assert.True(t, marked.Processing[1].UnixTime < marked.Processing[0].UnixTime)
+	assert.Equal(t, 1, marked.Processing[1].HLC.Compare(*marked.Processing[0].HLC))
+
+	// reverse the order, so sequence can't help either
+	marked.Processing[0].Sequence, marked.Processing[1].Sequence = 2, 1
+
+	last, err := SelectProcessByLast(marked)
+	assert.NoError(t, err)
+	assert.Equal(t, "mark", last.Name)
+
+	// a legacy step without an HLC means the old ordering
+	marked.Processing = append(marked.Processing, ProcessingDetails{Name: "legacy", Sequence: 0})
+	last, err = SelectProcessByLast(marked)
+	assert.NoError(t, err)
+	assert.Equal(t, "split", last.Name)
+}
+
+func TestClockUpdate(t *testing.T) {
+
+	wall := int64(100)
+	c := NewClock("a").WithWallClock(fixedClock(&wall))
+
+	c.Update(HLC{WallTime: 500, Logical: 3, Node: "b"})
+	assert.Equal(t, HLC{WallTime: 500, Logical: 5, Node: "a"}, *c.Stamp())
+
+	c.Update(HLC{WallTime: 500, Logical: 9, Node: "b"})
+	assert.Equal(t, HLC{WallTime: 500, Logical: 11, Node: "a"}, *c.Stamp())
+
+	c.Update(HLC{WallTime: 10})
+	assert.Equal(t, HLC{WallTime: 500, Logical: 13, Node: "a"}, *c.Stamp())
+
+	wall = 1000
+	c.Update(HLC{WallTime: 600})
+	assert.Equal(t, HLC{WallTime: 1000, Logical: 1, Node: "a"}, *c.Stamp())
+
+	// marking actions are observed too
+	q := QuestionDetails{Marking: []MarkingAction{MarkingAction{}}}
+	other := NewClock("b").WithWallClock(fixedClock(&wall))
+	wall = 5000
+	other.StampMarking(&q.Marking[0])
+	assert.Equal(t, int64(5000), q.Marking[0].UnixTime)
+
+	wall = 1000
+	c.Observe(PageData{Questions: []QuestionDetails{q}})
+	assert.Equal(t, 1, c.Stamp().Compare(*q.Marking[0].HLC))
+}
+
+func TestHLCCompare(t *testing.T) {
+
+	assert.Equal(t, 0, HLC{1, 2, "a", nil}.Compare(HLC{1, 2, "a", nil}))
+	assert.Equal(t, -1, HLC{1, 2, "a", nil}.Compare(HLC{1, 2, "b", nil}))
+	assert.Equal(t, -1, HLC{1, 2, "z", nil}.Compare(HLC{1, 3, "a", nil}))
+	assert.Equal(t, 1, HLC{2, 0, "a", nil}.Compare(HLC{1, 3, "z", nil}))
+}
+
+func TestHLCLegacyJSON(t *testing.T) {
+
+	// no HLC written for legacy records, so their hash doesn't change
+	data, err := json.Marshal(ProcessingDetails{Name: "split"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hlc")
+
+	var p ProcessingDetails
+	assert.NoError(t, json.Unmarshal([]byte(`{"name":"x","hlc":{"wallTime":5,"logical":1,"node":"n","skew":2}}`), &p))
+	assert.Equal(t, int64(5), p.HLC.WallTime)
+	assert.Equal(t, []string{"/processing/0/hlc/skew"}, UnknownFields(PageData{Processing: []ProcessingDetails{p}}))
+
+	out := protoRoundTrip(t, PageData{Processing: []ProcessingDetails{p, ProcessingDetails{}}})
+	assert.Equal(t, p.HLC, out.Processing[0].HLC)
+	assert.Nil(t, out.Processing[1].HLC)
+}
@@ -0,0 +1,183 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/timdrysdale/unipdf/v3/contentstream"
+	"github.com/timdrysdale/unipdf/v3/core"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+// font resource name used for page data added by incremental update
+const hiddenFontName = core.PdfObjectName("GXPD")
+
+var ErrSameFile = errors.New("input and output are the same file")
+
+// AppendPageDataToFile adds pd to page pageNum (counting from 1) of the
+// input file, and writes the result to outputPath as an incremental update.
+// The original bytes are copied unchanged, so any existing digital
+// signatures still verify. The output can't be the input, which would be
+// truncated before it was read.
+func AppendPageDataToFile(inputPath, outputPath string, pageNum int, pd *PageData) error {
+
+	in, err := os.Stat(inputPath)
+	if err != nil {
+		return err
+	}
+
+	if out, err := os.Stat(outputPath); err == nil && os.SameFile(in, out) {
+		return ErrSameFile
+	}
+
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	pdfReader, err := pdf.NewPdfReader(f)
+	if err != nil {
+		return err
+	}
+
+	of, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	err = AppendPageData(pdfReader, of, pageNum, pd)
+
+	// the update isn't written until the file is closed
+	if cerr := of.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// AppendPageData writes the document held by pdfReader to w, followed by
+// an incremental update (new objects and xref section) that adds pd to
+// page pageNum (counting from 1).
+func AppendPageData(pdfReader *pdf.PdfReader, w io.Writer, pageNum int, pd *PageData) error {
+
+	token, err := EncodeToken(pd, nil)
+	if err != nil {
+		return err
+	}
+
+	appender, err := pdf.NewPdfAppender(pdfReader)
+	if err != nil {
+		return err
+	}
+
+	page, err := pdfReader.GetPage(pageNum)
+	if err != nil {
+		return err
+	}
+
+	err = AppendPageString(page, StartTag+EscapeToken(token)+EndTag)
+	if err != nil {
+		return err
+	}
+
+	appender.UpdatePage(page)
+
+	return appender.Write(w)
+}
+
+// AppendPageString adds hidden text to an existing page's content, in the
+// same tiny font and off-page location used by WritePageString, but
+// without going through a creator. The text is encoded for the font, as
+// the creator would, so it reads back the same.
+func AppendPageString(page *pdf.PdfPage, text string) error {
+
+	if page.Resources == nil {
+		resources, err := inheritedResources(page)
+		if err != nil {
+			return err
+		}
+		page.Resources = resources
+	}
+
+	font, err := pdf.NewStandard14Font(pdf.HelveticaName)
+	if err != nil {
+		return err
+	}
+
+	if !page.Resources.HasFontByName(hiddenFontName) {
+		err = page.Resources.SetFontByName(hiddenFontName, font.ToPdfObject())
+		if err != nil {
+			return err
+		}
+	}
+
+	height := 0.0
+	mbox, err := page.GetMediaBox()
+	if err == nil && mbox != nil {
+		height = mbox.Height()
+	}
+
+	// creator measures y down from the top of the page, so flip it
+	// to put the text in the same place as WritePageString would
+	x, y := hiddenPosition()
+
+	cc := contentstream.NewContentCreator()
+	cc.Add_q().
+		Add_BT().
+		Add_Tf(hiddenFontName, hiddenFontSize).
+		Add_Td(x, height-y).
+		Add_Tj(*core.MakeStringFromBytes(font.Encoder().Encode(text))).
+		Add_ET().
+		Add_Q()
+
+	return page.AppendContentStream(cc.String())
+}
+
+// inheritedResources gives a page without its own resources a copy of
+// those it inherits from the page tree, so that setting the page's
+// resources doesn't drop them, nor change them for the other pages
+func inheritedResources(page *pdf.PdfPage) (*pdf.PdfPageResources, error) {
+
+	node := page.Parent
+
+	for depth := 0; depth < maxPageTreeDepth; depth++ {
+
+		parent, ok := core.GetDict(node)
+		if !ok {
+			break
+		}
+
+		if inherited, ok := core.GetDict(parent.Get("Resources")); ok {
+			return pdf.NewPdfPageResourcesFromDict(copyDict(inherited, "Font"))
+		}
+
+		node = parent.Get("Parent")
+	}
+
+	return pdf.NewPdfPageResources(), nil
+}
+
+// a page tree deeper than this is taken to have a loop in it
+const maxPageTreeDepth = 64
+
+// copyDict makes a shallow copy of a dictionary, and of the
+// sub-dictionaries named, so that new entries can be added to them
+func copyDict(d *core.PdfObjectDictionary, deep ...core.PdfObjectName) *core.PdfObjectDictionary {
+
+	out := core.MakeDict()
+
+	for _, key := range d.Keys() {
+		out.Set(key, d.Get(key))
+	}
+
+	for _, key := range deep {
+		if sub, ok := core.GetDict(d.Get(key)); ok {
+			out.Set(key, copyDict(sub))
+		}
+	}
+
+	return out
+}
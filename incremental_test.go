@@ -0,0 +1,211 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattetti/filebuffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/core"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+	"github.com/timdrysdale/unipdf/v3/model/sighandler"
+)
+
+func TestAppendPageDataKeepsSignature(t *testing.T) {
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	first := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}, Revision: 0}
+	assert.NoError(t, MarshalPageData(c, &first))
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Write(&buf))
+
+	signed := signForTest(t, buf.Bytes())
+
+	second := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}, Revision: 1}
+
+	var updated bytes.Buffer
+	assert.NoError(t, AppendPageData(readerForTest(t, signed), &updated, 1, &second))
+
+	// incremental update leaves the signed bytes untouched
+	assert.True(t, bytes.HasPrefix(updated.Bytes(), signed))
+
+	pdfReader := readerForTest(t, updated.Bytes())
+
+	handler, err := sighandler.NewAdobePKCS7Detached(nil, nil)
+	assert.NoError(t, err)
+
+	results, err := pdfReader.ValidateSignatures([]pdf.SignatureHandler{handler})
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(results)) {
+		assert.True(t, results[0].IsSigned)
+		assert.True(t, results[0].IsVerified)
+	}
+
+	page, err := pdfReader.GetPage(1)
+	assert.NoError(t, err)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pds))
+
+	pd, err := SelectPageDataByRevision(pds)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pd.Revision)
+}
+
+// signForTest signs a pdf with a throwaway self-signed certificate
+func signForTest(t *testing.T, data []byte) []byte {
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Exam Office"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appender, err := pdf.NewPdfAppender(readerForTest(t, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := sighandler.NewAdobePKCS7Detached(priv, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := pdf.NewPdfSignature(handler)
+	signature.SetName("Exam Office")
+	signature.SetReason("Released for marking")
+	signature.SetDate(time.Now(), "")
+
+	if err := signature.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	field := pdf.NewPdfFieldSignature(signature)
+	field.T = core.MakeString("ExamOffice")
+
+	if err := appender.Sign(1, field); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := appender.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func readerForTest(t *testing.T, data []byte) *pdf.PdfReader {
+
+	var bufslice []byte
+	fbuf := filebuffer.New(bufslice)
+	fbuf.Write(data)
+
+	pdfReader, err := pdf.NewPdfReader(fbuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pdfReader
+}
+
+func TestAppendPageDataNonASCII(t *testing.T) {
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.Write(&buf))
+
+	in := PageData{Author: AuthorDetails{Identity: "Zoë Ångström"}, Revision: 1}
+
+	var updated bytes.Buffer
+	assert.NoError(t, AppendPageData(readerForTest(t, buf.Bytes()), &updated, 1, &in))
+
+	page, err := readerForTest(t, updated.Bytes()).GetPage(1)
+	assert.NoError(t, err)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(pds)) {
+		assert.Equal(t, "Zoë Ångström", pds[0].Author.Identity)
+	}
+}
+
+func TestAppendPageDataInheritsResources(t *testing.T) {
+
+	fonts := core.MakeDict()
+	fonts.Set("F1", core.MakeDict())
+
+	resources := core.MakeDict()
+	resources.Set("Font", fonts)
+
+	parent := core.MakeDict()
+	parent.Set("Resources", resources)
+
+	page := pdf.NewPdfPage()
+	page.Parent = parent
+
+	assert.NoError(t, AppendPageString(page, "hidden"))
+
+	assert.True(t, page.Resources.HasFontByName("F1"))
+	assert.True(t, page.Resources.HasFontByName(hiddenFontName))
+
+	// the other pages sharing the parent's resources are left alone
+	assert.Nil(t, fonts.Get(hiddenFontName))
+}
+
+func TestAppendToSameFile(t *testing.T) {
+
+	dir, err := os.MkdirTemp("", "pdfpagedata")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	inputPath := filepath.Join(dir, "in.pdf")
+	assert.NoError(t, os.WriteFile(inputPath, []byte("%PDF-1.7"), 0644))
+
+	pd := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}}
+
+	for _, outputPath := range []string{inputPath, filepath.Join(dir, ".", "in.pdf")} {
+		assert.Equal(t, ErrSameFile, AppendPageDataToFile(inputPath, outputPath, 1, &pd))
+	}
+
+	// and the input is left alone
+	data, err := os.ReadFile(inputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "%PDF-1.7", string(data))
+}
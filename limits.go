@@ -0,0 +1,338 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/timdrysdale/unipdf/v3/core"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+// We read PDFs uploaded by students, so the page data in them is
+// untrusted. Limits stop a hostile file exhausting memory or time.
+// A zero value for any limit means no limit.
+type Limits struct {
+	MaxPages         int
+	MaxTokenSize     int // bytes
+	MaxTokensPerPage int
+	MaxDepth         int // nesting of Questions[].Parts
+	MaxTotalBytes    int // sum of all tokens decoded from a file
+	MaxContentBytes  int // a page's content streams, once decompressed
+}
+
+// DefaultLimits are generous for genuine exam scripts
+func DefaultLimits() Limits {
+	return Limits{
+		MaxPages:         5000,
+		MaxTokenSize:     1 << 20,
+		MaxTokensPerPage: 256,
+		MaxDepth:         16,
+		MaxTotalBytes:    256 << 20,
+		MaxContentBytes:  64 << 20,
+	}
+}
+
+// LimitError reports which limit was exceeded, and where
+type LimitError struct {
+	Limit string
+	Value int
+	Max   int
+	Page  int // counting from zero, -1 if not page specific
+}
+
+func (e *LimitError) Error() string {
+	if e.Page < 0 {
+		return fmt.Sprintf("%s of %d exceeds limit of %d", e.Limit, e.Value, e.Max)
+	}
+	return fmt.Sprintf("%s of %d exceeds limit of %d on page %d", e.Limit, e.Value, e.Max, e.Page)
+}
+
+func (opts ReadOptions) limits() Limits {
+	if opts.Limits == nil {
+		return DefaultLimits()
+	}
+	return *opts.Limits
+}
+
+func exceeds(value, max int) bool {
+	return max > 0 && value > max
+}
+
+func checkPageCount(numPages int, limits Limits) error {
+	if exceeds(numPages, limits.MaxPages) {
+		return &LimitError{Limit: "pages", Value: numPages, Max: limits.MaxPages, Page: -1}
+	}
+	return nil
+}
+
+// checkTokens checks the tokens from one page, adding their
+// size to the running total for the file
+func checkTokens(tokens []string, limits Limits, page int, total *int) error {
+
+	if exceeds(len(tokens), limits.MaxTokensPerPage) {
+		return &LimitError{Limit: "tokens per page", Value: len(tokens), Max: limits.MaxTokensPerPage, Page: page}
+	}
+
+	for _, token := range tokens {
+
+		if exceeds(len(token), limits.MaxTokenSize) {
+			return &LimitError{Limit: "token size", Value: len(token), Max: limits.MaxTokenSize, Page: page}
+		}
+
+		*total += len(token)
+
+		if exceeds(*total, limits.MaxTotalBytes) {
+			return &LimitError{Limit: "total bytes", Value: *total, Max: limits.MaxTotalBytes, Page: page}
+		}
+	}
+
+	return nil
+}
+
+// checkContent checks the size of a page's content before text is
+// extracted from it
+func checkContent(contents string, limits Limits, page int) error {
+	if exceeds(len(contents), limits.MaxContentBytes) {
+		return &LimitError{Limit: "content bytes", Value: len(contents), Max: limits.MaxContentBytes, Page: page}
+	}
+	return nil
+}
+
+// filterExpansion is the most that a filter can multiply the size of
+// its input by, for those filters found in content streams
+var filterExpansion = map[string]int{
+	"ASCIIHexDecode":  1,
+	"ASCII85Decode":   1,
+	"RunLengthDecode": 64,   // two bytes can give 128
+	"LZWDecode":       3412, // 9 bits can give 3838 bytes
+	"FlateDecode":     1032,
+}
+
+// checkContentStreams checks the size of a page's content before it is
+// decoded, so that a small stream that decodes to a huge one is never
+// held in memory. Flate, the usual filter, is inflated and counted, up
+// to the limit; otherwise the worst case is worked out from the size of
+// the stream as stored, so a stream with other filters is refused if it
+// only could be too big.
+func checkContentStreams(page *pdf.PdfPage, limits Limits, pageIndex int) error {
+
+	if limits.MaxContentBytes <= 0 {
+		return nil
+	}
+
+	total := 0
+
+	for _, stream := range contentStreams(page) {
+
+		size, err := decodedSize(stream, limits.MaxContentBytes-total)
+		if err != nil {
+			return err
+		}
+
+		total += size
+
+		if exceeds(total, limits.MaxContentBytes) {
+			return &LimitError{Limit: "content bytes", Value: total, Max: limits.MaxContentBytes, Page: pageIndex}
+		}
+	}
+
+	return nil
+}
+
+// contentStreams are the page's content, which is one stream or an
+// array of them
+func contentStreams(page *pdf.PdfPage) []*core.PdfObjectStream {
+
+	if stream, ok := core.GetStream(page.Contents); ok {
+		return []*core.PdfObjectStream{stream}
+	}
+
+	var streams []*core.PdfObjectStream
+
+	if arr, ok := core.GetArray(page.Contents); ok {
+		for _, obj := range arr.Elements() {
+			if stream, ok := core.GetStream(obj); ok {
+				streams = append(streams, stream)
+			}
+		}
+	}
+
+	return streams
+}
+
+// decodedSize is the size of the stream once decoded, or at least, as
+// big as it could be. Counting stops once it is over max.
+func decodedSize(stream *core.PdfObjectStream, max int) (int, error) {
+
+	var filters []string
+	var obj core.PdfObject
+
+	if stream.PdfObjectDictionary != nil {
+		obj = stream.PdfObjectDictionary.Get("Filter")
+	}
+
+	if name, ok := core.GetNameVal(obj); ok {
+		filters = []string{name}
+	} else if arr, ok := core.GetArray(obj); ok {
+		for _, o := range arr.Elements() {
+			name, ok := core.GetNameVal(o)
+			if !ok {
+				return 0, fmt.Errorf("content stream filter %v is not a name", o)
+			}
+			filters = append(filters, name)
+		}
+	}
+
+	size := len(stream.Stream)
+
+	for i, filter := range filters {
+
+		if size > max {
+			break
+		}
+
+		if i == 0 && filter == "FlateDecode" {
+			size = inflatedSize(stream.Stream, max)
+			continue
+		}
+
+		expansion, ok := filterExpansion[filter]
+		if !ok {
+			return 0, fmt.Errorf("content stream filter %s not supported", filter)
+		}
+
+		size *= expansion
+	}
+
+	return size, nil
+}
+
+// inflatedSize counts the bytes that data inflates to, stopping once it
+// is over max. A corrupt stream counts for as much as could be read.
+func inflatedSize(data []byte, max int) int {
+
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+
+	n, _ := io.Copy(io.Discard, io.LimitReader(r, int64(max)+1))
+
+	return int(n)
+}
+
+// a question sits three levels into a JSON record, each level of parts
+// adds two more, and the deepest of a question's own fields add four;
+// the rest leaves room for fields from newer versions
+const jsonDepthAllowance = 16
+
+// maxJSONDepth is the deepest nesting of JSON objects and arrays
+// allowed in a token, for a given limit on the depth of parts
+func maxJSONDepth(partsDepth int) int {
+	return 2*partsDepth + jsonDepthAllowance
+}
+
+// jsonDepth finds how deeply a JSON text nests, giving up as soon
+// as it goes deeper than max
+func jsonDepth(text string, max int) int {
+
+	depth := 0
+	deepest := 0
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(text); i++ {
+
+		c := text[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > deepest {
+				deepest = depth
+				if deepest > max {
+					return deepest
+				}
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+
+	return deepest
+}
+
+// DecodePageData unmarshals a single token, within the limits in opts,
+// and rejecting unknown fields if opts.Strict is set. JSON tokens are
+// checked for deep nesting before they are decoded.
+func DecodePageData(token string, opts ReadOptions) (PageData, error) {
+
+	var pd PageData
+
+	limits := opts.limits()
+
+	if exceeds(len(token), limits.MaxTokenSize) {
+		return pd, &LimitError{Limit: "token size", Value: len(token), Max: limits.MaxTokenSize, Page: -1}
+	}
+
+	if limits.MaxDepth > 0 && strings.HasPrefix(strings.TrimSpace(token), "{") {
+		max := maxJSONDepth(limits.MaxDepth)
+		if depth := jsonDepth(token, max); depth > max {
+			return pd, &LimitError{Limit: "nesting depth", Value: depth, Max: max, Page: -1}
+		}
+	}
+
+	if err := DecodeToken(token, &pd); err != nil {
+		return pd, err
+	}
+
+	depth := 0
+	for _, q := range pd.Questions {
+		if d := partsDepth(q); d > depth {
+			depth = d
+		}
+	}
+
+	if exceeds(depth, limits.MaxDepth) {
+		return PageData{}, &LimitError{Limit: "parts depth", Value: depth, Max: limits.MaxDepth, Page: -1}
+	}
+
+	if opts.Strict {
+		if paths := UnknownFields(pd); len(paths) > 0 {
+			return PageData{}, &UnknownFieldsError{Paths: paths}
+		}
+	}
+
+	return pd, nil
+}
+
+// partsDepth counts the levels of Parts below a question
+func partsDepth(q QuestionDetails) int {
+
+	depth := 0
+
+	for _, part := range q.Parts {
+		if d := partsDepth(part) + 1; d > depth {
+			depth = d
+		}
+	}
+
+	return depth
+}
@@ -0,0 +1,190 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/core"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+func TestCheckTokens(t *testing.T) {
+
+	limits := Limits{MaxTokenSize: 10, MaxTokensPerPage: 2, MaxTotalBytes: 15}
+
+	total := 0
+	assert.NoError(t, checkTokens([]string{"12345", "12345"}, limits, 0, &total))
+	assert.Equal(t, 10, total)
+
+	err := checkTokens([]string{"12345", "12345"}, limits, 1, &total)
+	if assert.Error(t, err) {
+		le := err.(*LimitError)
+		assert.Equal(t, "total bytes", le.Limit)
+		assert.Equal(t, 1, le.Page)
+	}
+
+	total = 0
+	err = checkTokens([]string{"1", "2", "3"}, limits, 0, &total)
+	if assert.Error(t, err) {
+		assert.Equal(t, "tokens per page", err.(*LimitError).Limit)
+	}
+
+	err = checkTokens([]string{strings.Repeat("x", 11)}, limits, 0, &total)
+	if assert.Error(t, err) {
+		assert.Equal(t, "token size", err.(*LimitError).Limit)
+	}
+
+	// zero is no limit
+	total = 0
+	assert.NoError(t, checkTokens([]string{strings.Repeat("x", 1000)}, Limits{}, 0, &total))
+
+	assert.NoError(t, checkPageCount(10, Limits{MaxPages: 10}))
+	assert.Error(t, checkPageCount(11, Limits{MaxPages: 10}))
+}
+
+func TestDecodePageDataDepth(t *testing.T) {
+
+	q := QuestionDetails{Name: "leaf"}
+	for i := 0; i < 5; i++ {
+		q = QuestionDetails{Parts: []QuestionDetails{q}}
+	}
+	assert.Equal(t, 5, partsDepth(q))
+
+	token, err := json.Marshal(PageData{Questions: []QuestionDetails{q}})
+	assert.NoError(t, err)
+
+	_, err = DecodePageData(string(token), ReadOptions{Limits: &Limits{MaxDepth: 4}})
+	if assert.Error(t, err) {
+		le, ok := err.(*LimitError)
+		assert.True(t, ok)
+		assert.Equal(t, "parts depth", le.Limit)
+		assert.Equal(t, 5, le.Value)
+	}
+
+	pd, err := DecodePageData(string(token), ReadOptions{Limits: &Limits{MaxDepth: 5}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pd.Questions))
+
+	_, err = DecodePageData(string(token), ReadOptions{Limits: &Limits{MaxTokenSize: 10}})
+	assert.Error(t, err)
+
+	// default limits apply when none are given
+	_, err = DecodePageData(strings.Repeat(" ", DefaultLimits().MaxTokenSize+1), ReadOptions{})
+	assert.Error(t, err)
+}
+
+func TestDecodePageDataNesting(t *testing.T) {
+
+	assert.Equal(t, 3, jsonDepth(`{"a":[{"b":"[[[{{{"}]}`, 10))
+	assert.Equal(t, 2, jsonDepth(`[{"a\"[":1}]`, 10))
+	assert.Equal(t, 0, jsonDepth(`"{"`, 10))
+
+	// gives up as soon as it is too deep
+	assert.Equal(t, 4, jsonDepth(strings.Repeat("[", 1000), 3))
+
+	// too deep to decode, however little is in it
+	token := `{"custom":` + strings.Repeat("[", 100) + strings.Repeat("]", 100) + `}`
+
+	_, err := DecodePageData(token, ReadOptions{Limits: &Limits{MaxDepth: 4}})
+	if assert.Error(t, err) {
+		le, ok := err.(*LimitError)
+		assert.True(t, ok)
+		assert.Equal(t, "nesting depth", le.Limit)
+		assert.Equal(t, maxJSONDepth(4), le.Max)
+	}
+
+	// a record with every kind of nested field, as deep as allowed
+	q := rubricQuestion()
+	q.Marking = []MarkingAction{MarkingAction{Criteria: []CriterionAward{CriterionAward{Criterion: "method"}}}}
+	for i := 0; i < 4; i++ {
+		q = QuestionDetails{Parts: []QuestionDetails{q}}
+	}
+
+	data, err := json.Marshal(PageData{Questions: []QuestionDetails{q}})
+	assert.NoError(t, err)
+
+	_, err = DecodePageData(string(data), ReadOptions{Limits: &Limits{MaxDepth: 4}})
+	assert.NoError(t, err)
+}
+
+func TestCheckContent(t *testing.T) {
+
+	assert.NoError(t, checkContent("BT ET", Limits{MaxContentBytes: 5}, 0))
+	assert.NoError(t, checkContent("BT ET", Limits{}, 0))
+
+	err := checkContent("BT  ET", Limits{MaxContentBytes: 5}, 2)
+	if assert.Error(t, err) {
+		le := err.(*LimitError)
+		assert.Equal(t, "content bytes", le.Limit)
+		assert.Equal(t, 2, le.Page)
+	}
+}
+
+func contentStream(data []byte, filters ...string) *core.PdfObjectStream {
+
+	dict := core.MakeDict()
+
+	if len(filters) == 1 {
+		dict.Set("Filter", core.MakeName(filters[0]))
+	} else if len(filters) > 1 {
+		var names []core.PdfObject
+		for _, f := range filters {
+			names = append(names, core.MakeName(f))
+		}
+		dict.Set("Filter", core.MakeArray(names...))
+	}
+
+	return &core.PdfObjectStream{PdfObjectDictionary: dict, Stream: data}
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestCheckContentStreams(t *testing.T) {
+
+	limits := Limits{MaxContentBytes: 1 << 20}
+
+	page := pdf.NewPdfPage()
+	page.Contents = contentStream(deflate([]byte("BT ET")), "FlateDecode")
+	assert.NoError(t, checkContentStreams(page, limits, 0))
+
+	// a bomb, small as stored, is only inflated as far as the limit
+	bomb := deflate(make([]byte, 64<<20))
+	assert.True(t, len(bomb) < 1<<20)
+
+	page.Contents = contentStream(bomb, "FlateDecode")
+	err := checkContentStreams(page, limits, 3)
+	if le, ok := err.(*LimitError); assert.True(t, ok) {
+		assert.Equal(t, "content bytes", le.Limit)
+		assert.Equal(t, 1<<20+1, le.Value)
+		assert.Equal(t, 3, le.Page)
+	}
+	assert.NoError(t, checkContentStreams(page, Limits{}, 3))
+
+	// streams add up
+	half := contentStream(make([]byte, 600<<10))
+	page.Contents = core.MakeArray(half, half)
+	assert.IsType(t, &LimitError{}, checkContentStreams(page, limits, 0))
+	page.Contents = core.MakeArray(half)
+	assert.NoError(t, checkContentStreams(page, limits, 0))
+
+	// other filters are judged by how big they could be
+	page.Contents = contentStream(make([]byte, 20<<10), "RunLengthDecode")
+	assert.IsType(t, &LimitError{}, checkContentStreams(page, limits, 0))
+	page.Contents = contentStream(make([]byte, 10<<10), "ASCIIHexDecode", "RunLengthDecode")
+	assert.NoError(t, checkContentStreams(page, limits, 0))
+	page.Contents = contentStream(deflate(make([]byte, 20<<10)), "FlateDecode", "RunLengthDecode")
+	assert.IsType(t, &LimitError{}, checkContentStreams(page, limits, 0))
+
+	page.Contents = contentStream([]byte("BT ET"), "DCTDecode")
+	assert.EqualError(t, checkContentStreams(page, limits, 0), "content stream filter DCTDecode not supported")
+}
@@ -0,0 +1,312 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Marks were float64, so that half and quarter marks added up over many
+// parts came out as 37.499999. A Mark is held exactly, in thousandths,
+// so sums are exact. In JSON it is still a plain number, e.g. 37.5, so
+// records are read and written the same as before; a number with more
+// than three decimal places, e.g. from float arithmetic, is rounded to
+// the nearest thousandth as it is read.
+//
+// Mark is a struct, rather than a count of thousandths, so that a
+// literal like MarksAvailable: 10 doesn't compile, instead of silently
+// meaning 0.01. Use NewMark(10) or ParseMark("10").
+//
+// Totals are exact; rounding to what is reported (e.g. whole marks,
+// halves up) is up to each exam, see Rounding and RoundingRules.
+
+const MarkPlaces = 3
+
+const markScale = 1000
+
+var (
+	ErrBadMark   = errors.New("not a valid mark")
+	ErrMarkRange = errors.New("mark out of range")
+)
+
+type Mark struct {
+	thousandths int64
+}
+
+// markPattern is a JSON number, which is all a Mark is written as
+var markPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE]([+-]?[0-9]+))?$`)
+
+// NewMark is the nearest mark to f, to a thousandth
+func NewMark(f float64) Mark {
+	return Mark{int64(math.Round(f * markScale))}
+}
+
+// ParseMark reads a mark written as a JSON number, exactly, then rounds
+// it to the nearest thousandth, halves away from zero
+func ParseMark(s string) (Mark, error) {
+
+	match := markPattern.FindStringSubmatch(s)
+	if match == nil {
+		return Mark{}, ErrBadMark
+	}
+
+	// size up the number from its digits before making it, so a huge
+	// exponent can't make a huge number: a tiny mark is zero, however
+	// small, and a huge one is out of range
+	if match[4] != "" {
+		lead, ok := leadingDigit(match[1], strings.TrimPrefix(match[2], "."))
+		if !ok {
+			return Mark{}, nil
+		}
+		exp, err := strconv.Atoi(match[4])
+		if err != nil {
+			if strings.HasPrefix(match[4], "-") {
+				return Mark{}, nil
+			}
+			return Mark{}, ErrMarkRange
+		}
+		// the mark is below 10^(lead+exp+1), so under half a thousandth
+		if lead+exp < -MarkPlaces-1 {
+			return Mark{}, nil
+		}
+		// the mark is at least 10^(lead+exp), too many thousandths for an int64
+		if lead+exp > 18-MarkPlaces {
+			return Mark{}, ErrMarkRange
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Mark{}, ErrBadMark
+	}
+
+	r.Mul(r, big.NewRat(markScale, 1))
+
+	num, den := r.Num(), r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	// round half away from zero
+	rem.Abs(rem).Mul(rem, big.NewInt(2))
+	if rem.Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if !q.IsInt64() {
+		return Mark{}, ErrMarkRange
+	}
+
+	return Mark{q.Int64()}, nil
+}
+
+// leadingDigit is the power of ten of the first non-zero digit of a
+// number written as whole.frac, e.g. 2 for 123.4 and -2 for 0.05, or
+// false if every digit is zero
+func leadingDigit(whole, frac string) (int, bool) {
+
+	if whole != "0" {
+		return len(whole) - 1, true
+	}
+
+	for i, c := range frac {
+		if c != '0' {
+			return -i - 1, true
+		}
+	}
+
+	return 0, false
+}
+
+// MarkThousandths is the mark of n thousandths, e.g. 37500 for 37.5
+func MarkThousandths(n int64) Mark {
+	return Mark{n}
+}
+
+// Thousandths is the mark as a whole number of thousandths, exactly,
+// e.g. for the protocol buffer
+func (m Mark) Thousandths() int64 {
+	return m.thousandths
+}
+
+// Float64 is the mark as a float, which may not be exact
+func (m Mark) Float64() float64 {
+	return float64(m.thousandths) / markScale
+}
+
+// String writes the mark with no more decimal places than it needs
+func (m Mark) String() string {
+
+	sign := ""
+	n := uint64(m.thousandths)
+	if m.thousandths < 0 {
+		sign = "-"
+		n = uint64(-m.thousandths)
+	}
+
+	whole, frac := n/markScale, n%markScale
+
+	if frac == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+
+	return sign + strings.TrimRight(fmt.Sprintf("%d.%03d", whole, frac), "0")
+}
+
+func (m Mark) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Mark) UnmarshalJSON(data []byte) error {
+
+	if string(data) == "null" {
+		return nil
+	}
+
+	parsed, err := ParseMark(string(data))
+	if err != nil {
+		return fmt.Errorf("mark %s: %v", data, err)
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+func (m Mark) Add(o Mark) Mark {
+	return Mark{m.thousandths + o.thousandths}
+}
+
+func (m Mark) Sub(o Mark) Mark {
+	return Mark{m.thousandths - o.thousandths}
+}
+
+// Cmp is -1, 0 or +1 as m is less than, equal to, or more than o
+func (m Mark) Cmp(o Mark) int {
+	switch {
+	case m.thousandths < o.thousandths:
+		return -1
+	case m.thousandths > o.thousandths:
+		return 1
+	}
+	return 0
+}
+
+func (m Mark) IsZero() bool {
+	return m.thousandths == 0
+}
+
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // halves away from zero
+	RoundHalfEven RoundingMode = iota // halves to the even neighbour
+	RoundDown     RoundingMode = iota // towards minus infinity
+	RoundUp       RoundingMode = iota // towards plus infinity
+)
+
+var roundingModeNames = []string{
+	RoundHalfUp:   "half-up",
+	RoundHalfEven: "half-even",
+	RoundDown:     "down",
+	RoundUp:       "up",
+}
+
+func (r RoundingMode) String() string {
+	if r < 0 || int(r) >= len(roundingModeNames) {
+		return fmt.Sprintf("rounding(%d)", int(r))
+	}
+	return roundingModeNames[r]
+}
+
+// MarshalText and UnmarshalText let a rounding mode be given by name in
+// a configuration file
+func (r RoundingMode) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *RoundingMode) UnmarshalText(text []byte) error {
+
+	for mode, name := range roundingModeNames {
+		if name == string(text) {
+			*r = RoundingMode(mode)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown rounding mode %q", text)
+}
+
+// Round rounds the mark to this many decimal places, e.g. 0 for whole
+// marks. Rounding to MarkPlaces or more changes nothing.
+func (m Mark) Round(places int, mode RoundingMode) Mark {
+
+	if places >= MarkPlaces {
+		return m
+	}
+
+	unit := int64(1)
+	for i := places; i < MarkPlaces; i++ {
+		unit *= 10
+	}
+
+	// q*unit + r, with 0 <= r < unit, so q is rounded down
+	q := m.thousandths / unit
+	r := m.thousandths % unit
+	if r < 0 {
+		q--
+		r += unit
+	}
+
+	switch mode {
+	case RoundUp:
+		if r > 0 {
+			q++
+		}
+	case RoundHalfUp:
+		if 2*r > unit || (2*r == unit && m.thousandths > 0) {
+			q++
+		}
+	case RoundHalfEven:
+		if 2*r > unit || (2*r == unit && q%2 != 0) {
+			q++
+		}
+	}
+
+	return Mark{q * unit}
+}
+
+// Rounding is how an exam's totals are reported
+type Rounding struct {
+	Places int          `json:"places" yaml:"places"`
+	Mode   RoundingMode `json:"mode" yaml:"mode"`
+}
+
+func (r Rounding) Round(m Mark) Mark {
+	return m.Round(r.Places, r.Mode)
+}
+
+// RoundingRules gives the rounding for each exam, keyed by the exam's
+// UUID, or course code, with "" for the rest. Exams without a rule
+// aren't rounded.
+type RoundingRules map[string]Rounding
+
+// For finds the rule for an exam
+func (rr RoundingRules) For(uuid, courseCode string) (Rounding, bool) {
+
+	for _, key := range []string{uuid, courseCode} {
+		if r, ok := rr[key]; ok && key != "" {
+			return r, true
+		}
+	}
+
+	r, ok := rr[""]
+
+	return r, ok
+}
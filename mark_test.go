@@ -0,0 +1,160 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMark(t *testing.T) {
+
+	cases := map[string]string{
+		"0":                               "0",
+		"4":                               "4",
+		"-0.25":                           "-0.25",
+		"37.5":                            "37.5",
+		"37.499999":                       "37.5",
+		"0.30000000000000004":             "0.3",
+		"0.0005":                          "0.001",
+		"-0.0005":                         "-0.001",
+		"0.0004999":                       "0",
+		"1e2":                             "100",
+		"125E-3":                          "0.125",
+		"1e-21":                           "0",
+		"-1e-400":                         "0",
+		"5e-99999999999999999":            "0",
+		"0e99999999999999999":             "0",
+		"12345678901234567890e-21":        "0.012",
+		"123456789012345678901234567e-21": "123456.789",
+		"0.00000000000000000000000001e30": "10000",
+		"5e-4":                            "0.001",
+	}
+
+	for in, want := range cases {
+		m, err := ParseMark(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, m.String(), in)
+	}
+
+	for _, bad := range []string{"", "1.", ".5", "+1", "01", "1/2", "0x10", "one", " 1"} {
+		_, err := ParseMark(bad)
+		assert.Equal(t, ErrBadMark, err, bad)
+	}
+
+	for _, big := range []string{"1e21", "1e16", "1e99999999999999999", "9223372036854775807"} {
+		_, err := ParseMark(big)
+		assert.Equal(t, ErrMarkRange, err, big)
+	}
+}
+
+func TestMarkSumIsExact(t *testing.T) {
+
+	var fsum float64
+	var msum Mark
+
+	// 0.1 has no exact float, so adding it up drifts
+	for i := 0; i < 375; i++ {
+		fsum += 0.1
+		msum = msum.Add(NewMark(0.1))
+	}
+
+	assert.NotEqual(t, 37.5, fsum)
+	assert.Equal(t, "37.5", msum.String())
+	assert.Equal(t, 37.5, msum.Float64())
+}
+
+func TestMarkJSON(t *testing.T) {
+
+	var md MarkDetails
+	assert.NoError(t, json.Unmarshal([]byte(`{"given":2.75,"available":4.0000000001}`), &md))
+	assert.Equal(t, NewMark(2.75), md.Given)
+	assert.Equal(t, NewMark(4), md.Available)
+
+	data, err := json.Marshal(md)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"given":2.75,"available":4,"comment":0}`, string(data))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"given":null}`), &md))
+	assert.Equal(t, NewMark(2.75), md.Given)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"given":"2"}`), &md))
+}
+
+func TestMarkCompare(t *testing.T) {
+
+	assert.Equal(t, -1, NewMark(1.5).Cmp(NewMark(2)))
+	assert.Equal(t, 0, NewMark(2).Cmp(NewMark(2)))
+	assert.Equal(t, 1, NewMark(2).Cmp(NewMark(1.999)))
+	assert.Equal(t, NewMark(0.5), NewMark(2).Sub(NewMark(1.5)))
+	assert.True(t, Mark{}.IsZero())
+}
+
+func TestMarkRound(t *testing.T) {
+
+	type roundCase struct {
+		mark   float64
+		places int
+		mode   RoundingMode
+		want   string
+	}
+
+	cases := []roundCase{
+		{37.5, 0, RoundHalfUp, "38"},
+		{38.5, 0, RoundHalfUp, "39"},
+		{-2.5, 0, RoundHalfUp, "-3"},
+		{37.499, 0, RoundHalfUp, "37"},
+		{37.5, 0, RoundHalfEven, "38"},
+		{38.5, 0, RoundHalfEven, "38"},
+		{-2.5, 0, RoundHalfEven, "-2"},
+		{37.9, 0, RoundDown, "37"},
+		{-0.1, 0, RoundDown, "-1"},
+		{37.1, 0, RoundUp, "38"},
+		{37, 0, RoundUp, "37"},
+		{2.25, 1, RoundHalfUp, "2.3"},
+		{2.25, 1, RoundHalfEven, "2.2"},
+		{2.125, 2, RoundHalfEven, "2.12"},
+		{2.125, 3, RoundDown, "2.125"},
+	}
+
+	for _, c := range cases {
+		got := NewMark(c.mark).Round(c.places, c.mode)
+		assert.Equal(t, c.want, got.String(), "%v to %d places %s", c.mark, c.places, c.mode)
+	}
+}
+
+func TestRoundingRules(t *testing.T) {
+
+	var rr RoundingRules
+	assert.NoError(t, json.Unmarshal([]byte(`{
+	  "e1": {"places": 0, "mode": "half-even"},
+	  "ENGI12123": {"places": 1, "mode": "up"},
+	  "": {"places": 0, "mode": "half-up"}
+	}`), &rr))
+
+	r, ok := rr.For("e1", "ENGI12123")
+	assert.True(t, ok)
+	assert.Equal(t, Rounding{Places: 0, Mode: RoundHalfEven}, r)
+
+	r, _ = rr.For("e2", "ENGI12123")
+	assert.Equal(t, RoundUp, r.Mode)
+
+	r, _ = rr.For("", "")
+	assert.Equal(t, RoundHalfUp, r.Mode)
+
+	_, ok = RoundingRules{}.For("e1", "")
+	assert.False(t, ok)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"": {"mode": "sideways"}}`), &rr))
+
+	ets := []ExamTotal{
+		ExamTotal{UUID: "e1", Scripts: []ScriptTotal{ScriptTotal{Total: Total{Awarded: NewMark(38.5)}}}},
+		ExamTotal{UUID: "e3", CourseCode: "ENGI12123", Scripts: []ScriptTotal{ScriptTotal{Total: Total{Awarded: NewMark(38.25)}}}},
+	}
+
+	rr.Apply(ets)
+
+	assert.Equal(t, NewMark(38), ets[0].Scripts[0].Rounded)
+	assert.Equal(t, NewMark(38.3), ets[1].Scripts[0].Rounded)
+	assert.Equal(t, NewMark(38.25), ets[1].Scripts[0].Awarded)
+}
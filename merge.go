@@ -0,0 +1,543 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Merge combines two copies of a page that were changed separately, e.g.
+// by two markers who each bumped Revision, using the revision they both
+// started from. Anything changed on only one side is kept. Questions,
+// marking actions and processing steps added on either side are all
+// kept, matching items by UUID where they have one. When both sides
+// changed the same thing differently, ours is kept and a Conflict is
+// reported for a human to resolve.
+//
+// The merged record's Revision is one more than the higher of the two.
+// If both sides added processing steps, a merge step is added after ours,
+// naming their last step as a second parent. Steps are hashed, Sequence
+// and all, so are never changed; if their steps reuse a Sequence number
+// already taken, the merge step records new numbers for them, in order,
+// after ours, and StepSequences gives the numbers to order steps by.
+
+const (
+	MergeStepName            = "merge"
+	MergeParentParameter     = "merge-parent"      // their last step
+	MergeParentHashParameter = "merge-parent-hash" // its StepHash
+	RenumberParameter        = "renumber"          // "<step UUID> <sequence>"
+)
+
+// MergeOptions control the merge step added when both sides added
+// processing steps
+type MergeOptions struct {
+	// By is who made the merge
+	By ContactDetails
+
+	// Clock stamps the merge step, if every step is stamped. A new
+	// clock is used if nil.
+	Clock *Clock
+}
+
+// Conflict is a field changed differently on each side. Base, Ours and
+// Theirs are the JSON values, or nil where the field was not present
+// (e.g. deleted on that side).
+type Conflict struct {
+	Path   string
+	Base   json.RawMessage
+	Ours   json.RawMessage
+	Theirs json.RawMessage
+}
+
+func (c Conflict) String() string {
+	show := func(v json.RawMessage) string {
+		if v == nil {
+			return "(absent)"
+		}
+		return string(v)
+	}
+	return fmt.Sprintf("%s: base %s, ours %s, theirs %s", c.Path, show(c.Base), show(c.Ours), show(c.Theirs))
+}
+
+// Merge three-way merges ours and theirs, which both descend from base
+func Merge(base, ours, theirs PageData) (PageData, []Conflict, error) {
+	return MergeWithOptions(base, ours, theirs, MergeOptions{})
+}
+
+// MergeWithOptions is Merge, with a say in how the merge step is recorded
+func MergeWithOptions(base, ours, theirs PageData, opts MergeOptions) (PageData, []Conflict, error) {
+
+	var docs [3]interface{}
+
+	for i, pd := range []PageData{base, ours, theirs} {
+		doc, err := toGeneric(pd)
+		if err != nil {
+			return PageData{}, nil, err
+		}
+		// handled separately, below
+		delete(doc.(map[string]interface{}), "revision")
+		docs[i] = doc
+	}
+
+	m := &merger{}
+
+	merged := m.value("", docs[0], docs[1], docs[2])
+	if m.err != nil {
+		return PageData{}, nil, m.err
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return PageData{}, nil, err
+	}
+
+	var pd PageData
+	if err := json.Unmarshal(data, &pd); err != nil {
+		return PageData{}, nil, err
+	}
+
+	if step, ok := mergeStep(pd.Processing, ours.Processing, theirs.Processing, opts); ok {
+		pd.Processing = append(pd.Processing, step)
+	}
+
+	pd.Revision = ours.Revision
+	if theirs.Revision > pd.Revision {
+		pd.Revision = theirs.Revision
+	}
+	pd.Revision++
+
+	return pd, m.conflicts, nil
+}
+
+// Ancestor is the latest record in the history older than both a and b,
+// to use as the base for merging them
+func (h History) Ancestor(a, b PageData) (PageData, error) {
+
+	below := a.Revision
+	if b.Revision < below {
+		below = b.Revision
+	}
+
+	for i := len(h) - 1; i >= 0; i-- {
+		if h[i].Revision < below {
+			return h[i], nil
+		}
+	}
+
+	return PageData{}, errors.New("no common ancestor")
+}
+
+// absent marks a field missing from one side
+type absentValue struct{}
+
+var absent = absentValue{}
+
+type merger struct {
+	conflicts []Conflict
+	err       error
+}
+
+func (m *merger) equal(a, b interface{}) bool {
+
+	_, aAbsent := a.(absentValue)
+	_, bAbsent := b.(absentValue)
+
+	if aAbsent || bAbsent {
+		return aAbsent && bAbsent
+	}
+
+	eq, err := genericEqual(a, b)
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+
+	return eq
+}
+
+func (m *merger) conflict(path string, base, ours, theirs interface{}) interface{} {
+
+	c := Conflict{Path: path}
+
+	for _, p := range []struct {
+		v   interface{}
+		raw *json.RawMessage
+	}{{base, &c.Base}, {ours, &c.Ours}, {theirs, &c.Theirs}} {
+		if _, ok := p.v.(absentValue); ok {
+			continue
+		}
+		data, err := json.Marshal(p.v)
+		if err != nil && m.err == nil {
+			m.err = err
+		}
+		*p.raw = data
+	}
+
+	m.conflicts = append(m.conflicts, c)
+
+	return ours
+}
+
+func (m *merger) value(path string, base, ours, theirs interface{}) interface{} {
+
+	switch {
+	case m.equal(ours, theirs):
+		return ours
+	case m.equal(base, ours):
+		return theirs
+	case m.equal(base, theirs):
+		return ours
+	}
+
+	if merged, ok := m.object(path, base, ours, theirs); ok {
+		return merged
+	}
+
+	if merged, ok := m.list(path, base, ours, theirs); ok {
+		return merged
+	}
+
+	return m.conflict(path, base, ours, theirs)
+}
+
+func (m *merger) object(path string, base, ours, theirs interface{}) (interface{}, bool) {
+
+	mo, ok := ours.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	mt, ok := theirs.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	mb, ok := base.(map[string]interface{})
+	if !ok {
+		if _, isAbsent := base.(absentValue); !isAbsent {
+			return nil, false
+		}
+		mb = map[string]interface{}{}
+	}
+
+	keys := make(map[string]bool)
+	for _, side := range []map[string]interface{}{mb, mo, mt} {
+		for k := range side {
+			keys[k] = true
+		}
+	}
+
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	merged := make(map[string]interface{})
+
+	for _, k := range sorted {
+		v := m.value(path+"/"+escapePointer(k), field(mb, k), field(mo, k), field(mt, k))
+		if _, ok := v.(absentValue); !ok {
+			merged[k] = v
+		}
+	}
+
+	return merged, true
+}
+
+func field(obj map[string]interface{}, key string) interface{} {
+	if v, ok := obj[key]; ok {
+		return v
+	}
+	return absent
+}
+
+// list merges lists of objects item by item, matched by listKey. Lists
+// that hold anything else, or in which two items match, are left to
+// conflict as a whole, except for marking actions, where the second
+// action by the same person is matched with their second on each side.
+func (m *merger) list(path string, base, ours, theirs interface{}) (interface{}, bool) {
+
+	var sides [3][]interface{}
+
+	for i, v := range []interface{}{base, ours, theirs} {
+		switch t := v.(type) {
+		case []interface{}:
+			sides[i] = t
+		case nil, absentValue:
+			// an empty list is written as null
+		default:
+			return nil, false
+		}
+	}
+
+	var keyed [3]map[string]interface{}
+	var order []string
+	seen := make(map[string]bool)
+
+	// ours first, so ours keeps its order, then what was added on theirs
+	for _, i := range []int{1, 2, 0} {
+
+		keyed[i] = make(map[string]interface{})
+		repeats := make(map[string]int)
+
+		for _, item := range sides[i] {
+
+			key, ok := listKey(path, item)
+			if !ok {
+				return nil, false
+			}
+
+			if _, dup := keyed[i][key]; dup {
+				if !actionList(path) {
+					return nil, false
+				}
+				repeats[key]++
+				key = fmt.Sprintf("%s#%d", key, repeats[key])
+			}
+
+			keyed[i][key] = item
+
+			if !seen[key] {
+				seen[key] = true
+				order = append(order, key)
+			}
+		}
+	}
+
+	merged := []interface{}{}
+
+	for _, key := range order {
+
+		get := func(i int) interface{} {
+			if v, ok := keyed[i][key]; ok {
+				return v
+			}
+			return absent
+		}
+
+		v := m.value(fmt.Sprintf("%s/%d", path, len(merged)), get(0), get(1), get(2))
+		if _, ok := v.(absentValue); !ok {
+			merged = append(merged, v)
+		}
+	}
+
+	return merged, true
+}
+
+// listKey identifies an item in a list across the three copies. Items
+// with a UUID are matched on it. Questions without one are matched on
+// their name, section and number, marking actions on who did them,
+// custom details on their name, rubric criteria and bands on their id,
+// and criteria awarded on the criterion. Anything else is matched only
+// if identical, so changing one counts as removing it and adding another.
+func listKey(path string, item interface{}) (string, bool) {
+
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+
+	if uuid, ok := obj["UUID"].(string); ok && uuid != "" {
+		return "uuid:" + uuid, true
+	}
+
+	last := path[strings.LastIndexByte(path, '/')+1:]
+
+	switch last {
+	case "questions", "parts":
+		return fmt.Sprintf("question:%v|%v|%v", obj["name"], obj["section"], obj["number"]), true
+	case "markers", "moderators", "checkers":
+		contact, _ := obj["contact"].(map[string]interface{})
+		return fmt.Sprintf("action:%v|%v|%v", obj["actor"], contact["UUID"], contact["email"]), true
+	case "custom":
+		if name, ok := obj["name"].(string); ok {
+			return "custom:" + name, true
+		}
+	case "rubric", "bands":
+		if id, ok := obj["id"].(string); ok {
+			return "id:" + id, true
+		}
+	case "criteria":
+		if id, ok := obj["criterion"].(string); ok {
+			return "criterion:" + id, true
+		}
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return "", false
+	}
+
+	canonical, err := CanonicalJSON(data)
+	if err != nil {
+		return "", false
+	}
+
+	return "json:" + string(canonical), true
+}
+
+func actionList(path string) bool {
+	switch path[strings.LastIndexByte(path, '/')+1:] {
+	case "markers", "moderators", "checkers":
+		return true
+	}
+	return false
+}
+
+// mergeStep follows the last steps on both sides, if each side has steps
+// the other doesn't, and renumbers any of theirs that reuse a sequence
+// number
+func mergeStep(merged, ours, theirs []ProcessingDetails, opts MergeOptions) (ProcessingDetails, bool) {
+
+	oi, ok := lastStep(ours, nil)
+	if !ok {
+		return ProcessingDetails{}, false
+	}
+
+	ti, ok := lastStep(theirs, nil)
+	if !ok {
+		return ProcessingDetails{}, false
+	}
+
+	oursHead, theirsHead := ours[oi], theirs[ti]
+
+	if oursHead.UUID == "" || theirsHead.UUID == "" ||
+		hasStep(ours, theirsHead.UUID) || hasStep(theirs, oursHead.UUID) {
+		return ProcessingDetails{}, false
+	}
+
+	step := ProcessingDetails{
+		UUID:     newUUID(),
+		Previous: oursHead.UUID,
+		UnixTime: time.Now().UnixNano(),
+		Name:     MergeStepName,
+		By:       opts.By,
+	}
+
+	step.PreviousHash, _ = StepHash(oursHead)
+	theirsHash, _ := StepHash(theirsHead)
+
+	params := []ParameterDetails{
+		ParameterDetails{Name: MergeParentParameter, Value: theirsHead.UUID},
+		ParameterDetails{Name: MergeParentHashParameter, Value: theirsHash},
+	}
+
+	before := StepSequences(merged)
+	seqs := append([]int(nil), before...)
+	max := renumbering(seqs)
+
+	for i, seq := range seqs {
+		if seq != before[i] {
+			params = append(params, ParameterDetails{
+				Name:  RenumberParameter,
+				Value: fmt.Sprintf("%s %d", merged[i].UUID, seq),
+			})
+		}
+	}
+
+	for i := range params {
+		params[i].Sequence = i + 1
+	}
+
+	step.Parameters = params
+	step.Sequence = max + 1
+
+	if allStamped(len(merged), func(i int) *HLC { return merged[i].HLC }) {
+		clock := opts.Clock
+		if clock == nil {
+			clock = NewClock("")
+		}
+		clock.Observe(PageData{Processing: merged})
+		step.HLC = clock.Stamp()
+	}
+
+	return step, true
+}
+
+func hasStep(steps []ProcessingDetails, uuid string) bool {
+
+	for _, step := range steps {
+		if step.UUID == uuid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renumbering finds the first step that reuses a sequence number, and
+// numbers it and every step after it, in order, after the steps before
+// it, returning the highest. Ours come first in a merged list, so it is
+// theirs that move.
+func renumbering(seqs []int) int {
+
+	max := 0
+	renumber := false
+	used := make(map[int]bool)
+
+	for i := range seqs {
+
+		if used[seqs[i]] {
+			renumber = true
+		}
+
+		if renumber {
+			seqs[i] = max + 1
+		}
+
+		used[seqs[i]] = true
+
+		if seqs[i] > max {
+			max = seqs[i]
+		}
+	}
+
+	return max
+}
+
+// StepSequences is the sequence number of each step, as renumbered by any
+// merge steps among them, to order the steps by. Later merges win.
+func StepSequences(steps []ProcessingDetails) []int {
+
+	seqs := make([]int, len(steps))
+	index := make(map[string]int)
+	var merges []int
+
+	for i, step := range steps {
+		seqs[i] = step.Sequence
+		if step.UUID != "" {
+			index[step.UUID] = i
+		}
+		if _, ok := stepParameter(step, RenumberParameter); ok {
+			merges = append(merges, i)
+		}
+	}
+
+	sort.SliceStable(merges, func(i, j int) bool {
+		return steps[merges[i]].Sequence < steps[merges[j]].Sequence
+	})
+
+	for _, m := range merges {
+		for _, p := range steps[m].Parameters {
+
+			if p.Name != RenumberParameter {
+				continue
+			}
+
+			var uuid string
+			var seq int
+			if _, err := fmt.Sscanf(p.Value, "%s %d", &uuid, &seq); err != nil {
+				continue
+			}
+
+			if i, ok := index[uuid]; ok {
+				seqs[i] = seq
+			}
+		}
+	}
+
+	return seqs
+}
@@ -0,0 +1,250 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mergeTestBase() PageData {
+	return PageData{
+		Exam:     ExamDetails{CourseCode: "ENGI12123", UUID: "69197384-fd15-42ac-ac16-82dbe4d52dd0"},
+		Page:     PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c", Number: 1, Of: 2},
+		Revision: 1,
+		Questions: []QuestionDetails{
+			QuestionDetails{UUID: "q1", Name: "Q1", MarksAvailable: NewMark(5)},
+			QuestionDetails{UUID: "q2", Name: "Q2", MarksAvailable: NewMark(5)},
+		},
+		Processing: []ProcessingDetails{
+			ProcessingDetails{UUID: "p1", Name: "split", Sequence: 1},
+		},
+	}
+}
+
+func TestMergeNoConflicts(t *testing.T) {
+
+	base := mergeTestBase()
+
+	ours := copyPageData(base)
+	ours.Revision = 2
+	ours.Questions[0].MarksAwarded = NewMark(3)
+	ours.Questions[0].Marking = []MarkingAction{MarkingAction{Actor: "alice", Done: true}}
+	ours.Processing = append(ours.Processing, ProcessingDetails{UUID: "p2", Name: "mark", Previous: "p1", Sequence: 2})
+
+	theirs := copyPageData(base)
+	theirs.Revision = 2
+	theirs.Questions[1].MarksAwarded = NewMark(4)
+	theirs.Questions[1].Marking = []MarkingAction{MarkingAction{Actor: "bob", Done: true}}
+	theirs.Questions = append(theirs.Questions, QuestionDetails{UUID: "q3", Name: "Q3"})
+	theirs.Processing = append(theirs.Processing, ProcessingDetails{UUID: "p3", Name: "mark", Previous: "p1", Sequence: 2})
+	theirs.Exam.Diet = "2020-Summer"
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	assert.Equal(t, 3, merged.Revision)
+	assert.Equal(t, "2020-Summer", merged.Exam.Diet)
+
+	if assert.Equal(t, 3, len(merged.Questions)) {
+		assert.Equal(t, NewMark(3.0), merged.Questions[0].MarksAwarded)
+		assert.Equal(t, "alice", merged.Questions[0].Marking[0].Actor)
+		assert.Equal(t, NewMark(4.0), merged.Questions[1].MarksAwarded)
+		assert.Equal(t, "bob", merged.Questions[1].Marking[0].Actor)
+		assert.Equal(t, "Q3", merged.Questions[2].Name)
+	}
+
+	// their step keeps its sequence, and the merge step renumbers it
+	if assert.Equal(t, 4, len(merged.Processing)) {
+		assert.Equal(t, []string{"p1", "p2", "p3"}, []string{merged.Processing[0].UUID, merged.Processing[1].UUID, merged.Processing[2].UUID})
+		assert.Equal(t, 2, merged.Processing[2].Sequence)
+		assert.Equal(t, "p1", merged.Processing[2].Previous)
+		assert.Equal(t, []int{1, 2, 3, 4}, StepSequences(merged.Processing))
+
+		step := merged.Processing[3]
+		assert.Equal(t, MergeStepName, step.Name)
+		assert.Equal(t, "p2", step.Previous)
+		parent, _ := stepParameter(step, MergeParentParameter)
+		assert.Equal(t, "p3", parent)
+		renumber, _ := stepParameter(step, RenumberParameter)
+		assert.Equal(t, "p3 3", renumber)
+	}
+
+	// the same either way round, apart from order and numbering
+	swapped, conflicts, err := Merge(base, theirs, ours)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, merged.Questions[0], swapped.Questions[0])
+	assert.Equal(t, 4, len(swapped.Processing))
+
+	// nothing added on theirs, so no merge step
+	merged, conflicts, err = Merge(base, ours, copyPageData(base))
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, 2, len(merged.Processing))
+}
+
+func TestMergeConflicts(t *testing.T) {
+
+	base := mergeTestBase()
+
+	ours := copyPageData(base)
+	ours.Revision = 2
+	ours.Questions[0].MarksAwarded = NewMark(3)
+	ours.Author.Anonymous = "B1"
+
+	theirs := copyPageData(base)
+	theirs.Revision = 5
+	theirs.Questions[0].MarksAwarded = NewMark(4)
+	theirs.Author.Anonymous = "B1"
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 6, merged.Revision)
+	assert.Equal(t, "B1", merged.Author.Anonymous)
+	assert.Equal(t, NewMark(3.0), merged.Questions[0].MarksAwarded, "ours kept")
+
+	if assert.Equal(t, 1, len(conflicts)) {
+		c := conflicts[0]
+		assert.Equal(t, "/questions/0/marksAwarded", c.Path)
+		assert.Equal(t, json.RawMessage("0"), c.Base)
+		assert.Equal(t, json.RawMessage("3"), c.Ours)
+		assert.Equal(t, json.RawMessage("4"), c.Theirs)
+		assert.Equal(t, "/questions/0/marksAwarded: base 0, ours 3, theirs 4", c.String())
+	}
+
+	// deleted on theirs but edited on ours
+	theirs = copyPageData(base)
+	theirs.Questions = theirs.Questions[1:]
+
+	merged, conflicts, err = Merge(base, ours, theirs)
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 1, len(conflicts)) {
+		assert.Equal(t, "/questions/0", conflicts[0].Path)
+		assert.Nil(t, conflicts[0].Theirs)
+	}
+	assert.Equal(t, 2, len(merged.Questions))
+
+	// deleted on theirs, untouched on ours, so deleted
+	ours = copyPageData(base)
+	merged, conflicts, err = Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, []QuestionDetails{base.Questions[1]}, merged.Questions)
+}
+
+func TestMergeMarkingActions(t *testing.T) {
+
+	base := mergeTestBase()
+	base.Questions[0].Marking = []MarkingAction{
+		MarkingAction{Actor: "alice", Mark: MarkDetails{Given: NewMark(2)}},
+		MarkingAction{Actor: "bob", Mark: MarkDetails{Given: NewMark(2)}},
+	}
+
+	// each changes a different action
+	ours := copyPageData(base)
+	ours.Questions[0].Marking[0].Mark.Given = NewMark(3)
+
+	theirs := copyPageData(base)
+	theirs.Questions[0].Marking[1].Done = true
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	if assert.Equal(t, 2, len(merged.Questions[0].Marking)) {
+		assert.Equal(t, NewMark(3), merged.Questions[0].Marking[0].Mark.Given)
+		assert.True(t, merged.Questions[0].Marking[1].Done)
+	}
+
+	// both change alice's, differently
+	theirs = copyPageData(base)
+	theirs.Questions[0].Marking[0].Mark.Given = NewMark(4)
+
+	merged, conflicts, err = Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(conflicts)) {
+		assert.Equal(t, "/questions/0/markers/0/mark/given", conflicts[0].Path)
+	}
+	if assert.Equal(t, 2, len(merged.Questions[0].Marking)) {
+		assert.Equal(t, NewMark(3), merged.Questions[0].Marking[0].Mark.Given, "ours kept")
+	}
+
+	// a second action by alice is matched with her second
+	base.Questions[0].Marking = append(base.Questions[0].Marking, MarkingAction{Actor: "alice"})
+	ours = copyPageData(base)
+	ours.Questions[0].Marking[2].Done = true
+	theirs = copyPageData(base)
+	theirs.Questions[0].Marking[0].Done = true
+
+	merged, conflicts, err = Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+	if assert.Equal(t, 3, len(merged.Questions[0].Marking)) {
+		assert.True(t, merged.Questions[0].Marking[0].Done)
+		assert.True(t, merged.Questions[0].Marking[2].Done)
+	}
+}
+
+func TestMergeRenumbersBranch(t *testing.T) {
+
+	base := mergeTestBase()
+
+	ours := copyPageData(base)
+	ours.Processing = append(ours.Processing, ProcessingDetails{UUID: "p2", Previous: "p1", Sequence: 2})
+
+	theirs := copyPageData(base)
+	theirs.Processing = append(theirs.Processing,
+		ProcessingDetails{UUID: "p3", Previous: "p1", Sequence: 2},
+		ProcessingDetails{UUID: "p4", Previous: "p3", Sequence: 3},
+		ProcessingDetails{UUID: "p5", Previous: "p4", Sequence: 4},
+	)
+
+	merged, conflicts, err := Merge(base, ours, theirs)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	var got []string
+	for i, seq := range StepSequences(merged.Processing) {
+		got = append(got, fmt.Sprintf("%s:%d", merged.Processing[i].UUID, seq))
+	}
+
+	if assert.Equal(t, 6, len(got)) {
+		assert.Equal(t, []string{"p1:1", "p2:2", "p3:3", "p4:4", "p5:5"}, got[:5])
+		assert.Equal(t, merged.Processing[5].UUID+":6", got[5])
+	}
+
+	// the steps themselves are unchanged
+	assert.Equal(t, theirs.Processing[1:], merged.Processing[2:5])
+
+	last, err := SelectProcessByLast(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, MergeStepName, last.Name)
+
+	// no sequence is used twice, once renumbered
+	for _, p := range Validate(merged) {
+		assert.NotContains(t, p.Path, "/sequence", p.String())
+	}
+}
+
+func TestHistoryAncestor(t *testing.T) {
+
+	h := NewHistory([]PageData{
+		PageData{Revision: 0},
+		PageData{Revision: 1, ToDo: "base"},
+		PageData{Revision: 2, ToDo: "ours"},
+		PageData{Revision: 2, ToDo: "theirs"},
+	})
+
+	ours, _ := h.Revision(2)
+
+	base, err := h.Ancestor(ours, PageData{Revision: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "base", base.ToDo)
+
+	_, err = h.Ancestor(ours, PageData{Revision: 0})
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/timdrysdale/unipdf/v3/creator"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+// Other applications can hide their own per-page records using the
+// same mechanism, by choosing their own namespace for the tags, e.g.
+// <plagiarism-check>...</plagiarism-check>. Readers only see tokens
+// in the namespace they ask for, so they don't trip over each other.
+
+const DefaultNamespace = "gradex-pagedata"
+
+var ErrBadNamespace = errors.New("namespace must be lower case letters, digits, '.', '_' or '-'")
+
+var namespaceRegexp = regexp.MustCompile(`^[a-z][a-z0-9._-]*$`)
+
+var startTagRegexp = regexp.MustCompile(`<([a-z][a-z0-9._-]*)>`)
+
+func ValidNamespace(namespace string) bool {
+	return namespaceRegexp.MatchString(namespace)
+}
+
+func StartTagFor(namespace string) string {
+	return "<" + namespace + ">"
+}
+
+func EndTagFor(namespace string) string {
+	return "</" + namespace + ">"
+}
+
+func WriteNamespaceData(c *creator.Creator, namespace, text string) error {
+
+	if !ValidNamespace(namespace) {
+		return ErrBadNamespace
+	}
+
+	WritePageString(c, StartTagFor(namespace)+EscapeToken(text)+EndTagFor(namespace))
+
+	return nil
+}
+
+func ReadNamespaceData(page *pdf.PdfPage, namespace string) ([]string, error) {
+
+	text, err := ReadPageString(page)
+
+	if err != nil {
+		return []string{text}, err
+	}
+
+	return ExtractNamespaceData(text, namespace), nil
+}
+
+// ReadAllNamespaceData returns the tokens on the page grouped by namespace
+func ReadAllNamespaceData(page *pdf.PdfPage) (map[string][]string, error) {
+
+	text, err := ReadPageString(page)
+
+	if err != nil {
+		return map[string][]string{}, err
+	}
+
+	return ExtractAllNamespaceData(text), nil
+}
+
+// ExtractNamespaceData finds the tokens in one namespace, unescaped
+func ExtractNamespaceData(pageText, namespace string) []string {
+	return unescapeTokens(extractTokens(pageText, namespace))
+}
+
+// extractTokens finds the tokens in one namespace, as written
+func extractTokens(pageText, namespace string) []string {
+
+	var tokens []string
+
+	startTag := StartTagFor(namespace)
+	endTag := EndTagFor(namespace)
+
+LOOP:
+	for {
+
+		startIndex := strings.Index(pageText, startTag)
+		if startIndex < 0 {
+			break LOOP
+		}
+
+		pageText = pageText[startIndex+len(startTag):]
+
+		endIndex := strings.Index(pageText, endTag)
+		if endIndex < 0 {
+			break LOOP
+		}
+
+		tokens = append(tokens, pageText[:endIndex])
+
+		pageText = pageText[endIndex+len(endTag):]
+
+	}
+
+	return tokens
+}
+
+// ExtractAllNamespaceData finds tokens in every namespace present in the
+// text, unescaped
+func ExtractAllNamespaceData(pageText string) map[string][]string {
+
+	tokens := make(map[string][]string)
+
+	for {
+
+		loc := startTagRegexp.FindStringSubmatchIndex(pageText)
+		if loc == nil {
+			break
+		}
+
+		namespace := pageText[loc[2]:loc[3]]
+		rest := pageText[loc[1]:]
+
+		endIndex := strings.Index(rest, EndTagFor(namespace))
+		if endIndex < 0 {
+			// not a token, just something that looks like a tag
+			pageText = rest
+			continue
+		}
+
+		tokens[namespace] = append(tokens[namespace], UnescapeToken(rest[:endIndex]))
+
+		pageText = rest[endIndex+len(EndTagFor(namespace)):]
+	}
+
+	return tokens
+}
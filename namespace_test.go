@@ -0,0 +1,67 @@
+package pdfpagedata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+)
+
+func TestExtractNamespaceData(t *testing.T) {
+
+	text := "some text <gradex-pagedata>{\"a\":1}</gradex-pagedata> more\n" +
+		"<plagiarism-check>{\"score\":0.1}</plagiarism-check>\n" +
+		"a < b > c <notatag> and <gradex-pagedata>{\"a\":2}</gradex-pagedata>\n" +
+		"<accessibility.review>ok</accessibility.review>"
+
+	assert.Equal(t, []string{"{\"a\":1}", "{\"a\":2}"}, ExtractPageData(text))
+	assert.Equal(t, []string{"{\"score\":0.1}"}, ExtractNamespaceData(text, "plagiarism-check"))
+	assert.Equal(t, 0, len(ExtractNamespaceData(text, "notatag")))
+
+	all := ExtractAllNamespaceData(text)
+	assert.Equal(t, 3, len(all))
+	assert.Equal(t, []string{"{\"a\":1}", "{\"a\":2}"}, all[DefaultNamespace])
+	assert.Equal(t, []string{"{\"score\":0.1}"}, all["plagiarism-check"])
+	assert.Equal(t, []string{"ok"}, all["accessibility.review"])
+
+	// end tag before start tag
+	assert.Equal(t, 0, len(ExtractPageData(EndTag+"x"+StartTag)))
+}
+
+func TestValidNamespace(t *testing.T) {
+	assert.True(t, ValidNamespace(DefaultNamespace))
+	assert.True(t, ValidNamespace("plagiarism-check"))
+	assert.False(t, ValidNamespace(""))
+	assert.False(t, ValidNamespace("Upper"))
+	assert.False(t, ValidNamespace("with space"))
+	assert.False(t, ValidNamespace("a>b"))
+
+	c := creator.New()
+	assert.Equal(t, ErrBadNamespace, WriteNamespaceData(c, "<bad>", "x"))
+}
+
+func TestWriteReadNamespaces(t *testing.T) {
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	pd := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}}
+	assert.NoError(t, MarshalPageData(c, &pd))
+	assert.NoError(t, WriteNamespaceData(c, "plagiarism-check", "{\"score\":0.1}"))
+
+	page := firstPageRoundTrip(t, c)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pds))
+
+	tokens, err := ReadNamespaceData(page, "plagiarism-check")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"{\"score\":0.1}"}, tokens)
+
+	all, err := ReadAllNamespaceData(page)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(all))
+}
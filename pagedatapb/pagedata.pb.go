@@ -0,0 +1,1770 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: pagedatapb/pagedata.proto
+
+// Protocol Buffers mirror of the types in github.com/timdrysdale/pdfpagedata,
+// for services in other languages. Use pdfpagedata.ToProto and FromProto to
+// convert. Field names follow types.go; the JSON names differ in places.
+//
+// Fields that a record had but this version of the Go types did not know
+// about are kept in extra, keyed by JSON name, with the raw JSON as value.
+//
+// Marks are exact, so they are carried as whole thousandths of a mark,
+// e.g. 37.5 marks is 37500. Empty lists are the same as missing ones.
+
+package pagedatapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type PageData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exam          *ExamDetails           `protobuf:"bytes,1,opt,name=exam,proto3" json:"exam,omitempty"`
+	Author        *AuthorDetails         `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Page          *PageDetails           `protobuf:"bytes,3,opt,name=page,proto3" json:"page,omitempty"`
+	Contact       *ContactDetails        `protobuf:"bytes,4,opt,name=contact,proto3" json:"contact,omitempty"`
+	Submission    *SubmissionDetails     `protobuf:"bytes,5,opt,name=submission,proto3" json:"submission,omitempty"`
+	Questions     []*QuestionDetails     `protobuf:"bytes,6,rep,name=questions,proto3" json:"questions,omitempty"`
+	Processing    []*ProcessingDetails   `protobuf:"bytes,7,rep,name=processing,proto3" json:"processing,omitempty"`
+	Custom        []*CustomDetails       `protobuf:"bytes,8,rep,name=custom,proto3" json:"custom,omitempty"`
+	Revision      int64                  `protobuf:"varint,9,opt,name=revision,proto3" json:"revision,omitempty"`
+	PreparedFor   string                 `protobuf:"bytes,10,opt,name=prepared_for,json=preparedFor,proto3" json:"prepared_for,omitempty"`
+	ToDo          string                 `protobuf:"bytes,11,opt,name=to_do,json=toDo,proto3" json:"to_do,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PageData) Reset() {
+	*x = PageData{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PageData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PageData) ProtoMessage() {}
+
+func (x *PageData) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PageData.ProtoReflect.Descriptor instead.
+func (*PageData) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PageData) GetExam() *ExamDetails {
+	if x != nil {
+		return x.Exam
+	}
+	return nil
+}
+
+func (x *PageData) GetAuthor() *AuthorDetails {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *PageData) GetPage() *PageDetails {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+func (x *PageData) GetContact() *ContactDetails {
+	if x != nil {
+		return x.Contact
+	}
+	return nil
+}
+
+func (x *PageData) GetSubmission() *SubmissionDetails {
+	if x != nil {
+		return x.Submission
+	}
+	return nil
+}
+
+func (x *PageData) GetQuestions() []*QuestionDetails {
+	if x != nil {
+		return x.Questions
+	}
+	return nil
+}
+
+func (x *PageData) GetProcessing() []*ProcessingDetails {
+	if x != nil {
+		return x.Processing
+	}
+	return nil
+}
+
+func (x *PageData) GetCustom() []*CustomDetails {
+	if x != nil {
+		return x.Custom
+	}
+	return nil
+}
+
+func (x *PageData) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *PageData) GetPreparedFor() string {
+	if x != nil {
+		return x.PreparedFor
+	}
+	return ""
+}
+
+func (x *PageData) GetToDo() string {
+	if x != nil {
+		return x.ToDo
+	}
+	return ""
+}
+
+func (x *PageData) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type SubmissionDetails struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	FilePrefix       string                 `protobuf:"bytes,1,opt,name=file_prefix,json=filePrefix,proto3" json:"file_prefix,omitempty"`
+	OriginalFilename string                 `protobuf:"bytes,2,opt,name=original_filename,json=originalFilename,proto3" json:"original_filename,omitempty"`
+	OriginalFormat   string                 `protobuf:"bytes,3,opt,name=original_format,json=originalFormat,proto3" json:"original_format,omitempty"`
+	NewFilename      string                 `protobuf:"bytes,4,opt,name=new_filename,json=newFilename,proto3" json:"new_filename,omitempty"`
+	NewFormat        string                 `protobuf:"bytes,5,opt,name=new_format,json=newFormat,proto3" json:"new_format,omitempty"`
+	Extra            map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SubmissionDetails) Reset() {
+	*x = SubmissionDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmissionDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmissionDetails) ProtoMessage() {}
+
+func (x *SubmissionDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmissionDetails.ProtoReflect.Descriptor instead.
+func (*SubmissionDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubmissionDetails) GetFilePrefix() string {
+	if x != nil {
+		return x.FilePrefix
+	}
+	return ""
+}
+
+func (x *SubmissionDetails) GetOriginalFilename() string {
+	if x != nil {
+		return x.OriginalFilename
+	}
+	return ""
+}
+
+func (x *SubmissionDetails) GetOriginalFormat() string {
+	if x != nil {
+		return x.OriginalFormat
+	}
+	return ""
+}
+
+func (x *SubmissionDetails) GetNewFilename() string {
+	if x != nil {
+		return x.NewFilename
+	}
+	return ""
+}
+
+func (x *SubmissionDetails) GetNewFormat() string {
+	if x != nil {
+		return x.NewFormat
+	}
+	return ""
+}
+
+func (x *SubmissionDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type ExamDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CourseCode    string                 `protobuf:"bytes,1,opt,name=course_code,json=courseCode,proto3" json:"course_code,omitempty"`
+	Diet          string                 `protobuf:"bytes,2,opt,name=diet,proto3" json:"diet,omitempty"`
+	Date          string                 `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	Uuid          string                 `protobuf:"bytes,4,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExamDetails) Reset() {
+	*x = ExamDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExamDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExamDetails) ProtoMessage() {}
+
+func (x *ExamDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExamDetails.ProtoReflect.Descriptor instead.
+func (*ExamDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExamDetails) GetCourseCode() string {
+	if x != nil {
+		return x.CourseCode
+	}
+	return ""
+}
+
+func (x *ExamDetails) GetDiet() string {
+	if x != nil {
+		return x.Diet
+	}
+	return ""
+}
+
+func (x *ExamDetails) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *ExamDetails) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ExamDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type AuthorDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Anonymous     string                 `protobuf:"bytes,1,opt,name=anonymous,proto3" json:"anonymous,omitempty"`
+	Identity      string                 `protobuf:"bytes,2,opt,name=identity,proto3" json:"identity,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthorDetails) Reset() {
+	*x = AuthorDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthorDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthorDetails) ProtoMessage() {}
+
+func (x *AuthorDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthorDetails.ProtoReflect.Descriptor instead.
+func (*AuthorDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AuthorDetails) GetAnonymous() string {
+	if x != nil {
+		return x.Anonymous
+	}
+	return ""
+}
+
+func (x *AuthorDetails) GetIdentity() string {
+	if x != nil {
+		return x.Identity
+	}
+	return ""
+}
+
+func (x *AuthorDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type PageDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Number        int64                  `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
+	Of            int64                  `protobuf:"varint,3,opt,name=of,proto3" json:"of,omitempty"`
+	Filename      string                 `protobuf:"bytes,4,opt,name=filename,proto3" json:"filename,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PageDetails) Reset() {
+	*x = PageDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PageDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PageDetails) ProtoMessage() {}
+
+func (x *PageDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PageDetails.ProtoReflect.Descriptor instead.
+func (*PageDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PageDetails) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *PageDetails) GetNumber() int64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *PageDetails) GetOf() int64 {
+	if x != nil {
+		return x.Of
+	}
+	return 0
+}
+
+func (x *PageDetails) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *PageDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type ContactDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Uuid          string                 `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Address       string                 `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactDetails) Reset() {
+	*x = ContactDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactDetails) ProtoMessage() {}
+
+func (x *ContactDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactDetails.ProtoReflect.Descriptor instead.
+func (*ContactDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ContactDetails) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ContactDetails) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ContactDetails) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ContactDetails) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ContactDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type QuestionDetails struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	Uuid                      string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Name                      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Section                   string                 `protobuf:"bytes,3,opt,name=section,proto3" json:"section,omitempty"`
+	Number                    int64                  `protobuf:"varint,4,opt,name=number,proto3" json:"number,omitempty"`
+	Parts                     []*QuestionDetails     `protobuf:"bytes,5,rep,name=parts,proto3" json:"parts,omitempty"`
+	Marking                   []*MarkingAction       `protobuf:"bytes,8,rep,name=marking,proto3" json:"marking,omitempty"`
+	Moderating                []*MarkingAction       `protobuf:"bytes,9,rep,name=moderating,proto3" json:"moderating,omitempty"`
+	Checking                  []*MarkingAction       `protobuf:"bytes,10,rep,name=checking,proto3" json:"checking,omitempty"`
+	Sequence                  int64                  `protobuf:"varint,11,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	UnixTime                  int64                  `protobuf:"varint,12,opt,name=unix_time,json=unixTime,proto3" json:"unix_time,omitempty"`
+	Previous                  string                 `protobuf:"bytes,13,opt,name=previous,proto3" json:"previous,omitempty"`
+	Hlc                       *HLC                   `protobuf:"bytes,14,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	PreviousHash              string                 `protobuf:"bytes,15,opt,name=previous_hash,json=previousHash,proto3" json:"previous_hash,omitempty"`
+	Rubric                    []*CriterionDetails    `protobuf:"bytes,16,rep,name=rubric,proto3" json:"rubric,omitempty"`
+	MarksAvailableThousandths int64                  `protobuf:"zigzag64,17,opt,name=marks_available_thousandths,json=marksAvailableThousandths,proto3" json:"marks_available_thousandths,omitempty"`
+	MarksAwardedThousandths   int64                  `protobuf:"zigzag64,18,opt,name=marks_awarded_thousandths,json=marksAwardedThousandths,proto3" json:"marks_awarded_thousandths,omitempty"`
+	Extra                     map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *QuestionDetails) Reset() {
+	*x = QuestionDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuestionDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuestionDetails) ProtoMessage() {}
+
+func (x *QuestionDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuestionDetails.ProtoReflect.Descriptor instead.
+func (*QuestionDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *QuestionDetails) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *QuestionDetails) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *QuestionDetails) GetSection() string {
+	if x != nil {
+		return x.Section
+	}
+	return ""
+}
+
+func (x *QuestionDetails) GetNumber() int64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *QuestionDetails) GetParts() []*QuestionDetails {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+func (x *QuestionDetails) GetMarking() []*MarkingAction {
+	if x != nil {
+		return x.Marking
+	}
+	return nil
+}
+
+func (x *QuestionDetails) GetModerating() []*MarkingAction {
+	if x != nil {
+		return x.Moderating
+	}
+	return nil
+}
+
+func (x *QuestionDetails) GetChecking() []*MarkingAction {
+	if x != nil {
+		return x.Checking
+	}
+	return nil
+}
+
+func (x *QuestionDetails) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *QuestionDetails) GetUnixTime() int64 {
+	if x != nil {
+		return x.UnixTime
+	}
+	return 0
+}
+
+func (x *QuestionDetails) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+func (x *QuestionDetails) GetHlc() *HLC {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+func (x *QuestionDetails) GetPreviousHash() string {
+	if x != nil {
+		return x.PreviousHash
+	}
+	return ""
+}
+
+func (x *QuestionDetails) GetRubric() []*CriterionDetails {
+	if x != nil {
+		return x.Rubric
+	}
+	return nil
+}
+
+func (x *QuestionDetails) GetMarksAvailableThousandths() int64 {
+	if x != nil {
+		return x.MarksAvailableThousandths
+	}
+	return 0
+}
+
+func (x *QuestionDetails) GetMarksAwardedThousandths() int64 {
+	if x != nil {
+		return x.MarksAwardedThousandths
+	}
+	return 0
+}
+
+func (x *QuestionDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type MarkingAction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Actor         string                 `protobuf:"bytes,1,opt,name=actor,proto3" json:"actor,omitempty"`
+	Contact       *ContactDetails        `protobuf:"bytes,2,opt,name=contact,proto3" json:"contact,omitempty"`
+	Mark          *MarkDetails           `protobuf:"bytes,3,opt,name=mark,proto3" json:"mark,omitempty"`
+	Done          bool                   `protobuf:"varint,4,opt,name=done,proto3" json:"done,omitempty"`
+	UnixTime      int64                  `protobuf:"varint,5,opt,name=unix_time,json=unixTime,proto3" json:"unix_time,omitempty"`
+	Custom        *CustomDetails         `protobuf:"bytes,6,opt,name=custom,proto3" json:"custom,omitempty"`
+	Hlc           *HLC                   `protobuf:"bytes,7,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	Criteria      []*CriterionAward      `protobuf:"bytes,8,rep,name=criteria,proto3" json:"criteria,omitempty"`
+	Uuid          string                 `protobuf:"bytes,9,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Previous      string                 `protobuf:"bytes,10,opt,name=previous,proto3" json:"previous,omitempty"`
+	PreviousHash  string                 `protobuf:"bytes,11,opt,name=previous_hash,json=previousHash,proto3" json:"previous_hash,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkingAction) Reset() {
+	*x = MarkingAction{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkingAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkingAction) ProtoMessage() {}
+
+func (x *MarkingAction) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkingAction.ProtoReflect.Descriptor instead.
+func (*MarkingAction) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MarkingAction) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *MarkingAction) GetContact() *ContactDetails {
+	if x != nil {
+		return x.Contact
+	}
+	return nil
+}
+
+func (x *MarkingAction) GetMark() *MarkDetails {
+	if x != nil {
+		return x.Mark
+	}
+	return nil
+}
+
+func (x *MarkingAction) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *MarkingAction) GetUnixTime() int64 {
+	if x != nil {
+		return x.UnixTime
+	}
+	return 0
+}
+
+func (x *MarkingAction) GetCustom() *CustomDetails {
+	if x != nil {
+		return x.Custom
+	}
+	return nil
+}
+
+func (x *MarkingAction) GetHlc() *HLC {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+func (x *MarkingAction) GetCriteria() []*CriterionAward {
+	if x != nil {
+		return x.Criteria
+	}
+	return nil
+}
+
+func (x *MarkingAction) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *MarkingAction) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+func (x *MarkingAction) GetPreviousHash() string {
+	if x != nil {
+		return x.PreviousHash
+	}
+	return ""
+}
+
+func (x *MarkingAction) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type MarkDetails struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Comment              float64                `protobuf:"fixed64,3,opt,name=comment,proto3" json:"comment,omitempty"`
+	GivenThousandths     int64                  `protobuf:"zigzag64,4,opt,name=given_thousandths,json=givenThousandths,proto3" json:"given_thousandths,omitempty"`
+	AvailableThousandths int64                  `protobuf:"zigzag64,5,opt,name=available_thousandths,json=availableThousandths,proto3" json:"available_thousandths,omitempty"`
+	Extra                map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *MarkDetails) Reset() {
+	*x = MarkDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkDetails) ProtoMessage() {}
+
+func (x *MarkDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkDetails.ProtoReflect.Descriptor instead.
+func (*MarkDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MarkDetails) GetComment() float64 {
+	if x != nil {
+		return x.Comment
+	}
+	return 0
+}
+
+func (x *MarkDetails) GetGivenThousandths() int64 {
+	if x != nil {
+		return x.GivenThousandths
+	}
+	return 0
+}
+
+func (x *MarkDetails) GetAvailableThousandths() int64 {
+	if x != nil {
+		return x.AvailableThousandths
+	}
+	return 0
+}
+
+func (x *MarkDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type CriterionDetails struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Descriptor_      string                 `protobuf:"bytes,2,opt,name=descriptor,proto3" json:"descriptor,omitempty"`
+	Bands            []*BandDetails         `protobuf:"bytes,4,rep,name=bands,proto3" json:"bands,omitempty"`
+	MarksThousandths int64                  `protobuf:"zigzag64,5,opt,name=marks_thousandths,json=marksThousandths,proto3" json:"marks_thousandths,omitempty"`
+	Extra            map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CriterionDetails) Reset() {
+	*x = CriterionDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CriterionDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CriterionDetails) ProtoMessage() {}
+
+func (x *CriterionDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CriterionDetails.ProtoReflect.Descriptor instead.
+func (*CriterionDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CriterionDetails) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CriterionDetails) GetDescriptor_() string {
+	if x != nil {
+		return x.Descriptor_
+	}
+	return ""
+}
+
+func (x *CriterionDetails) GetBands() []*BandDetails {
+	if x != nil {
+		return x.Bands
+	}
+	return nil
+}
+
+func (x *CriterionDetails) GetMarksThousandths() int64 {
+	if x != nil {
+		return x.MarksThousandths
+	}
+	return 0
+}
+
+func (x *CriterionDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type BandDetails struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Descriptor_    string                 `protobuf:"bytes,2,opt,name=descriptor,proto3" json:"descriptor,omitempty"`
+	MinThousandths int64                  `protobuf:"zigzag64,5,opt,name=min_thousandths,json=minThousandths,proto3" json:"min_thousandths,omitempty"`
+	MaxThousandths int64                  `protobuf:"zigzag64,6,opt,name=max_thousandths,json=maxThousandths,proto3" json:"max_thousandths,omitempty"`
+	Extra          map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BandDetails) Reset() {
+	*x = BandDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BandDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BandDetails) ProtoMessage() {}
+
+func (x *BandDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BandDetails.ProtoReflect.Descriptor instead.
+func (*BandDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BandDetails) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BandDetails) GetDescriptor_() string {
+	if x != nil {
+		return x.Descriptor_
+	}
+	return ""
+}
+
+func (x *BandDetails) GetMinThousandths() int64 {
+	if x != nil {
+		return x.MinThousandths
+	}
+	return 0
+}
+
+func (x *BandDetails) GetMaxThousandths() int64 {
+	if x != nil {
+		return x.MaxThousandths
+	}
+	return 0
+}
+
+func (x *BandDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+// mark is unset for a band with only one mark in it
+type CriterionAward struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Criterion       string                 `protobuf:"bytes,1,opt,name=criterion,proto3" json:"criterion,omitempty"`
+	Band            string                 `protobuf:"bytes,2,opt,name=band,proto3" json:"band,omitempty"`
+	MarkThousandths *int64                 `protobuf:"zigzag64,4,opt,name=mark_thousandths,json=markThousandths,proto3,oneof" json:"mark_thousandths,omitempty"`
+	Extra           map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CriterionAward) Reset() {
+	*x = CriterionAward{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CriterionAward) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CriterionAward) ProtoMessage() {}
+
+func (x *CriterionAward) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CriterionAward.ProtoReflect.Descriptor instead.
+func (*CriterionAward) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CriterionAward) GetCriterion() string {
+	if x != nil {
+		return x.Criterion
+	}
+	return ""
+}
+
+func (x *CriterionAward) GetBand() string {
+	if x != nil {
+		return x.Band
+	}
+	return ""
+}
+
+func (x *CriterionAward) GetMarkThousandths() int64 {
+	if x != nil && x.MarkThousandths != nil {
+		return *x.MarkThousandths
+	}
+	return 0
+}
+
+func (x *CriterionAward) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type CustomDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CustomDetails) Reset() {
+	*x = CustomDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomDetails) ProtoMessage() {}
+
+func (x *CustomDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomDetails.ProtoReflect.Descriptor instead.
+func (*CustomDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CustomDetails) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *CustomDetails) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *CustomDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type ProcessingDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uuid          string                 `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Previous      string                 `protobuf:"bytes,2,opt,name=previous,proto3" json:"previous,omitempty"`
+	UnixTime      int64                  `protobuf:"varint,3,opt,name=unix_time,json=unixTime,proto3" json:"unix_time,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Parameters    []*ParameterDetails    `protobuf:"bytes,5,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	By            *ContactDetails        `protobuf:"bytes,6,opt,name=by,proto3" json:"by,omitempty"`
+	Sequence      int64                  `protobuf:"varint,7,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Hlc           *HLC                   `protobuf:"bytes,8,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	PreviousHash  string                 `protobuf:"bytes,9,opt,name=previous_hash,json=previousHash,proto3" json:"previous_hash,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessingDetails) Reset() {
+	*x = ProcessingDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessingDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessingDetails) ProtoMessage() {}
+
+func (x *ProcessingDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessingDetails.ProtoReflect.Descriptor instead.
+func (*ProcessingDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ProcessingDetails) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *ProcessingDetails) GetPrevious() string {
+	if x != nil {
+		return x.Previous
+	}
+	return ""
+}
+
+func (x *ProcessingDetails) GetUnixTime() int64 {
+	if x != nil {
+		return x.UnixTime
+	}
+	return 0
+}
+
+func (x *ProcessingDetails) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProcessingDetails) GetParameters() []*ParameterDetails {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *ProcessingDetails) GetBy() *ContactDetails {
+	if x != nil {
+		return x.By
+	}
+	return nil
+}
+
+func (x *ProcessingDetails) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ProcessingDetails) GetHlc() *HLC {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+func (x *ProcessingDetails) GetPreviousHash() string {
+	if x != nil {
+		return x.PreviousHash
+	}
+	return ""
+}
+
+func (x *ProcessingDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type ParameterDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Sequence      int64                  `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParameterDetails) Reset() {
+	*x = ParameterDetails{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParameterDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParameterDetails) ProtoMessage() {}
+
+func (x *ParameterDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParameterDetails.ProtoReflect.Descriptor instead.
+func (*ParameterDetails) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ParameterDetails) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ParameterDetails) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ParameterDetails) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ParameterDetails) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+// Hybrid logical clock timestamp; absent on records from older tools
+type HLC struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WallTime      int64                  `protobuf:"varint,1,opt,name=wall_time,json=wallTime,proto3" json:"wall_time,omitempty"`
+	Logical       int64                  `protobuf:"varint,2,opt,name=logical,proto3" json:"logical,omitempty"`
+	Node          string                 `protobuf:"bytes,3,opt,name=node,proto3" json:"node,omitempty"`
+	Extra         map[string]string      `protobuf:"bytes,100,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HLC) Reset() {
+	*x = HLC{}
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HLC) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HLC) ProtoMessage() {}
+
+func (x *HLC) ProtoReflect() protoreflect.Message {
+	mi := &file_pagedatapb_pagedata_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HLC.ProtoReflect.Descriptor instead.
+func (*HLC) Descriptor() ([]byte, []int) {
+	return file_pagedatapb_pagedata_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HLC) GetWallTime() int64 {
+	if x != nil {
+		return x.WallTime
+	}
+	return 0
+}
+
+func (x *HLC) GetLogical() int64 {
+	if x != nil {
+		return x.Logical
+	}
+	return 0
+}
+
+func (x *HLC) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *HLC) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+var File_pagedatapb_pagedata_proto protoreflect.FileDescriptor
+
+const file_pagedatapb_pagedata_proto_rawDesc = "" +
+	"\n" +
+	"\x19pagedatapb/pagedata.proto\x12\x0epdfpagedata.v1\"\xa2\x05\n" +
+	"\bPageData\x12/\n" +
+	"\x04exam\x18\x01 \x01(\v2\x1b.pdfpagedata.v1.ExamDetailsR\x04exam\x125\n" +
+	"\x06author\x18\x02 \x01(\v2\x1d.pdfpagedata.v1.AuthorDetailsR\x06author\x12/\n" +
+	"\x04page\x18\x03 \x01(\v2\x1b.pdfpagedata.v1.PageDetailsR\x04page\x128\n" +
+	"\acontact\x18\x04 \x01(\v2\x1e.pdfpagedata.v1.ContactDetailsR\acontact\x12A\n" +
+	"\n" +
+	"submission\x18\x05 \x01(\v2!.pdfpagedata.v1.SubmissionDetailsR\n" +
+	"submission\x12=\n" +
+	"\tquestions\x18\x06 \x03(\v2\x1f.pdfpagedata.v1.QuestionDetailsR\tquestions\x12A\n" +
+	"\n" +
+	"processing\x18\a \x03(\v2!.pdfpagedata.v1.ProcessingDetailsR\n" +
+	"processing\x125\n" +
+	"\x06custom\x18\b \x03(\v2\x1d.pdfpagedata.v1.CustomDetailsR\x06custom\x12\x1a\n" +
+	"\brevision\x18\t \x01(\x03R\brevision\x12!\n" +
+	"\fprepared_for\x18\n" +
+	" \x01(\tR\vpreparedFor\x12\x13\n" +
+	"\x05to_do\x18\v \x01(\tR\x04toDo\x129\n" +
+	"\x05extra\x18d \x03(\v2#.pdfpagedata.v1.PageData.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xca\x02\n" +
+	"\x11SubmissionDetails\x12\x1f\n" +
+	"\vfile_prefix\x18\x01 \x01(\tR\n" +
+	"filePrefix\x12+\n" +
+	"\x11original_filename\x18\x02 \x01(\tR\x10originalFilename\x12'\n" +
+	"\x0foriginal_format\x18\x03 \x01(\tR\x0eoriginalFormat\x12!\n" +
+	"\fnew_filename\x18\x04 \x01(\tR\vnewFilename\x12\x1d\n" +
+	"\n" +
+	"new_format\x18\x05 \x01(\tR\tnewFormat\x12B\n" +
+	"\x05extra\x18d \x03(\v2,.pdfpagedata.v1.SubmissionDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe2\x01\n" +
+	"\vExamDetails\x12\x1f\n" +
+	"\vcourse_code\x18\x01 \x01(\tR\n" +
+	"courseCode\x12\x12\n" +
+	"\x04diet\x18\x02 \x01(\tR\x04diet\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\tR\x04date\x12\x12\n" +
+	"\x04uuid\x18\x04 \x01(\tR\x04uuid\x12<\n" +
+	"\x05extra\x18d \x03(\v2&.pdfpagedata.v1.ExamDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc3\x01\n" +
+	"\rAuthorDetails\x12\x1c\n" +
+	"\tanonymous\x18\x01 \x01(\tR\tanonymous\x12\x1a\n" +
+	"\bidentity\x18\x02 \x01(\tR\bidentity\x12>\n" +
+	"\x05extra\x18d \x03(\v2(.pdfpagedata.v1.AuthorDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdd\x01\n" +
+	"\vPageDetails\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x16\n" +
+	"\x06number\x18\x02 \x01(\x03R\x06number\x12\x0e\n" +
+	"\x02of\x18\x03 \x01(\x03R\x02of\x12\x1a\n" +
+	"\bfilename\x18\x04 \x01(\tR\bfilename\x12<\n" +
+	"\x05extra\x18d \x03(\v2&.pdfpagedata.v1.PageDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xe3\x01\n" +
+	"\x0eContactDetails\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04uuid\x18\x02 \x01(\tR\x04uuid\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aaddress\x12?\n" +
+	"\x05extra\x18d \x03(\v2).pdfpagedata.v1.ContactDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb4\x06\n" +
+	"\x0fQuestionDetails\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\asection\x18\x03 \x01(\tR\asection\x12\x16\n" +
+	"\x06number\x18\x04 \x01(\x03R\x06number\x125\n" +
+	"\x05parts\x18\x05 \x03(\v2\x1f.pdfpagedata.v1.QuestionDetailsR\x05parts\x127\n" +
+	"\amarking\x18\b \x03(\v2\x1d.pdfpagedata.v1.MarkingActionR\amarking\x12=\n" +
+	"\n" +
+	"moderating\x18\t \x03(\v2\x1d.pdfpagedata.v1.MarkingActionR\n" +
+	"moderating\x129\n" +
+	"\bchecking\x18\n" +
+	" \x03(\v2\x1d.pdfpagedata.v1.MarkingActionR\bchecking\x12\x1a\n" +
+	"\bsequence\x18\v \x01(\x03R\bsequence\x12\x1b\n" +
+	"\tunix_time\x18\f \x01(\x03R\bunixTime\x12\x1a\n" +
+	"\bprevious\x18\r \x01(\tR\bprevious\x12%\n" +
+	"\x03hlc\x18\x0e \x01(\v2\x13.pdfpagedata.v1.HLCR\x03hlc\x12#\n" +
+	"\rprevious_hash\x18\x0f \x01(\tR\fpreviousHash\x128\n" +
+	"\x06rubric\x18\x10 \x03(\v2 .pdfpagedata.v1.CriterionDetailsR\x06rubric\x12>\n" +
+	"\x1bmarks_available_thousandths\x18\x11 \x01(\x12R\x19marksAvailableThousandths\x12:\n" +
+	"\x19marks_awarded_thousandths\x18\x12 \x01(\x12R\x17marksAwardedThousandths\x12@\n" +
+	"\x05extra\x18d \x03(\v2*.pdfpagedata.v1.QuestionDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\x06\x10\aJ\x04\b\a\x10\b\"\xaa\x04\n" +
+	"\rMarkingAction\x12\x14\n" +
+	"\x05actor\x18\x01 \x01(\tR\x05actor\x128\n" +
+	"\acontact\x18\x02 \x01(\v2\x1e.pdfpagedata.v1.ContactDetailsR\acontact\x12/\n" +
+	"\x04mark\x18\x03 \x01(\v2\x1b.pdfpagedata.v1.MarkDetailsR\x04mark\x12\x12\n" +
+	"\x04done\x18\x04 \x01(\bR\x04done\x12\x1b\n" +
+	"\tunix_time\x18\x05 \x01(\x03R\bunixTime\x125\n" +
+	"\x06custom\x18\x06 \x01(\v2\x1d.pdfpagedata.v1.CustomDetailsR\x06custom\x12%\n" +
+	"\x03hlc\x18\a \x01(\v2\x13.pdfpagedata.v1.HLCR\x03hlc\x12:\n" +
+	"\bcriteria\x18\b \x03(\v2\x1e.pdfpagedata.v1.CriterionAwardR\bcriteria\x12\x12\n" +
+	"\x04uuid\x18\t \x01(\tR\x04uuid\x12\x1a\n" +
+	"\bprevious\x18\n" +
+	" \x01(\tR\bprevious\x12#\n" +
+	"\rprevious_hash\x18\v \x01(\tR\fpreviousHash\x12>\n" +
+	"\x05extra\x18d \x03(\v2(.pdfpagedata.v1.MarkingAction.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8d\x02\n" +
+	"\vMarkDetails\x12\x18\n" +
+	"\acomment\x18\x03 \x01(\x01R\acomment\x12+\n" +
+	"\x11given_thousandths\x18\x04 \x01(\x12R\x10givenThousandths\x123\n" +
+	"\x15available_thousandths\x18\x05 \x01(\x12R\x14availableThousandths\x12<\n" +
+	"\x05extra\x18d \x03(\v2&.pdfpagedata.v1.MarkDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\x01\x10\x02J\x04\b\x02\x10\x03\"\xa5\x02\n" +
+	"\x10CriterionDetails\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1e\n" +
+	"\n" +
+	"descriptor\x18\x02 \x01(\tR\n" +
+	"descriptor\x121\n" +
+	"\x05bands\x18\x04 \x03(\v2\x1b.pdfpagedata.v1.BandDetailsR\x05bands\x12+\n" +
+	"\x11marks_thousandths\x18\x05 \x01(\x12R\x10marksThousandths\x12A\n" +
+	"\x05extra\x18d \x03(\v2+.pdfpagedata.v1.CriterionDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\x03\x10\x04\"\x93\x02\n" +
+	"\vBandDetails\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1e\n" +
+	"\n" +
+	"descriptor\x18\x02 \x01(\tR\n" +
+	"descriptor\x12'\n" +
+	"\x0fmin_thousandths\x18\x05 \x01(\x12R\x0eminThousandths\x12'\n" +
+	"\x0fmax_thousandths\x18\x06 \x01(\x12R\x0emaxThousandths\x12<\n" +
+	"\x05extra\x18d \x03(\v2&.pdfpagedata.v1.BandDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\x03\x10\x04J\x04\b\x04\x10\x05\"\x88\x02\n" +
+	"\x0eCriterionAward\x12\x1c\n" +
+	"\tcriterion\x18\x01 \x01(\tR\tcriterion\x12\x12\n" +
+	"\x04band\x18\x02 \x01(\tR\x04band\x12.\n" +
+	"\x10mark_thousandths\x18\x04 \x01(\x12H\x00R\x0fmarkThousandths\x88\x01\x01\x12?\n" +
+	"\x05extra\x18d \x03(\v2).pdfpagedata.v1.CriterionAward.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x13\n" +
+	"\x11_mark_thousandthsJ\x04\b\x03\x10\x04\"\xb1\x01\n" +
+	"\rCustomDetails\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12>\n" +
+	"\x05extra\x18d \x03(\v2(.pdfpagedata.v1.CustomDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xcc\x03\n" +
+	"\x11ProcessingDetails\x12\x12\n" +
+	"\x04uuid\x18\x01 \x01(\tR\x04uuid\x12\x1a\n" +
+	"\bprevious\x18\x02 \x01(\tR\bprevious\x12\x1b\n" +
+	"\tunix_time\x18\x03 \x01(\x03R\bunixTime\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12@\n" +
+	"\n" +
+	"parameters\x18\x05 \x03(\v2 .pdfpagedata.v1.ParameterDetailsR\n" +
+	"parameters\x12.\n" +
+	"\x02by\x18\x06 \x01(\v2\x1e.pdfpagedata.v1.ContactDetailsR\x02by\x12\x1a\n" +
+	"\bsequence\x18\a \x01(\x03R\bsequence\x12%\n" +
+	"\x03hlc\x18\b \x01(\v2\x13.pdfpagedata.v1.HLCR\x03hlc\x12#\n" +
+	"\rprevious_hash\x18\t \x01(\tR\fpreviousHash\x12B\n" +
+	"\x05extra\x18d \x03(\v2,.pdfpagedata.v1.ProcessingDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd5\x01\n" +
+	"\x10ParameterDetails\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x03R\bsequence\x12A\n" +
+	"\x05extra\x18d \x03(\v2+.pdfpagedata.v1.ParameterDetails.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc0\x01\n" +
+	"\x03HLC\x12\x1b\n" +
+	"\twall_time\x18\x01 \x01(\x03R\bwallTime\x12\x18\n" +
+	"\alogical\x18\x02 \x01(\x03R\alogical\x12\x12\n" +
+	"\x04node\x18\x03 \x01(\tR\x04node\x124\n" +
+	"\x05extra\x18d \x03(\v2\x1e.pdfpagedata.v1.HLC.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B/Z-github.com/timdrysdale/pdfpagedata/pagedatapbb\x06proto3"
+
+var (
+	file_pagedatapb_pagedata_proto_rawDescOnce sync.Once
+	file_pagedatapb_pagedata_proto_rawDescData []byte
+)
+
+func file_pagedatapb_pagedata_proto_rawDescGZIP() []byte {
+	file_pagedatapb_pagedata_proto_rawDescOnce.Do(func() {
+		file_pagedatapb_pagedata_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pagedatapb_pagedata_proto_rawDesc), len(file_pagedatapb_pagedata_proto_rawDesc)))
+	})
+	return file_pagedatapb_pagedata_proto_rawDescData
+}
+
+var file_pagedatapb_pagedata_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_pagedatapb_pagedata_proto_goTypes = []any{
+	(*PageData)(nil),          // 0: pdfpagedata.v1.PageData
+	(*SubmissionDetails)(nil), // 1: pdfpagedata.v1.SubmissionDetails
+	(*ExamDetails)(nil),       // 2: pdfpagedata.v1.ExamDetails
+	(*AuthorDetails)(nil),     // 3: pdfpagedata.v1.AuthorDetails
+	(*PageDetails)(nil),       // 4: pdfpagedata.v1.PageDetails
+	(*ContactDetails)(nil),    // 5: pdfpagedata.v1.ContactDetails
+	(*QuestionDetails)(nil),   // 6: pdfpagedata.v1.QuestionDetails
+	(*MarkingAction)(nil),     // 7: pdfpagedata.v1.MarkingAction
+	(*MarkDetails)(nil),       // 8: pdfpagedata.v1.MarkDetails
+	(*CriterionDetails)(nil),  // 9: pdfpagedata.v1.CriterionDetails
+	(*BandDetails)(nil),       // 10: pdfpagedata.v1.BandDetails
+	(*CriterionAward)(nil),    // 11: pdfpagedata.v1.CriterionAward
+	(*CustomDetails)(nil),     // 12: pdfpagedata.v1.CustomDetails
+	(*ProcessingDetails)(nil), // 13: pdfpagedata.v1.ProcessingDetails
+	(*ParameterDetails)(nil),  // 14: pdfpagedata.v1.ParameterDetails
+	(*HLC)(nil),               // 15: pdfpagedata.v1.HLC
+	nil,                       // 16: pdfpagedata.v1.PageData.ExtraEntry
+	nil,                       // 17: pdfpagedata.v1.SubmissionDetails.ExtraEntry
+	nil,                       // 18: pdfpagedata.v1.ExamDetails.ExtraEntry
+	nil,                       // 19: pdfpagedata.v1.AuthorDetails.ExtraEntry
+	nil,                       // 20: pdfpagedata.v1.PageDetails.ExtraEntry
+	nil,                       // 21: pdfpagedata.v1.ContactDetails.ExtraEntry
+	nil,                       // 22: pdfpagedata.v1.QuestionDetails.ExtraEntry
+	nil,                       // 23: pdfpagedata.v1.MarkingAction.ExtraEntry
+	nil,                       // 24: pdfpagedata.v1.MarkDetails.ExtraEntry
+	nil,                       // 25: pdfpagedata.v1.CriterionDetails.ExtraEntry
+	nil,                       // 26: pdfpagedata.v1.BandDetails.ExtraEntry
+	nil,                       // 27: pdfpagedata.v1.CriterionAward.ExtraEntry
+	nil,                       // 28: pdfpagedata.v1.CustomDetails.ExtraEntry
+	nil,                       // 29: pdfpagedata.v1.ProcessingDetails.ExtraEntry
+	nil,                       // 30: pdfpagedata.v1.ParameterDetails.ExtraEntry
+	nil,                       // 31: pdfpagedata.v1.HLC.ExtraEntry
+}
+var file_pagedatapb_pagedata_proto_depIdxs = []int32{
+	2,  // 0: pdfpagedata.v1.PageData.exam:type_name -> pdfpagedata.v1.ExamDetails
+	3,  // 1: pdfpagedata.v1.PageData.author:type_name -> pdfpagedata.v1.AuthorDetails
+	4,  // 2: pdfpagedata.v1.PageData.page:type_name -> pdfpagedata.v1.PageDetails
+	5,  // 3: pdfpagedata.v1.PageData.contact:type_name -> pdfpagedata.v1.ContactDetails
+	1,  // 4: pdfpagedata.v1.PageData.submission:type_name -> pdfpagedata.v1.SubmissionDetails
+	6,  // 5: pdfpagedata.v1.PageData.questions:type_name -> pdfpagedata.v1.QuestionDetails
+	13, // 6: pdfpagedata.v1.PageData.processing:type_name -> pdfpagedata.v1.ProcessingDetails
+	12, // 7: pdfpagedata.v1.PageData.custom:type_name -> pdfpagedata.v1.CustomDetails
+	16, // 8: pdfpagedata.v1.PageData.extra:type_name -> pdfpagedata.v1.PageData.ExtraEntry
+	17, // 9: pdfpagedata.v1.SubmissionDetails.extra:type_name -> pdfpagedata.v1.SubmissionDetails.ExtraEntry
+	18, // 10: pdfpagedata.v1.ExamDetails.extra:type_name -> pdfpagedata.v1.ExamDetails.ExtraEntry
+	19, // 11: pdfpagedata.v1.AuthorDetails.extra:type_name -> pdfpagedata.v1.AuthorDetails.ExtraEntry
+	20, // 12: pdfpagedata.v1.PageDetails.extra:type_name -> pdfpagedata.v1.PageDetails.ExtraEntry
+	21, // 13: pdfpagedata.v1.ContactDetails.extra:type_name -> pdfpagedata.v1.ContactDetails.ExtraEntry
+	6,  // 14: pdfpagedata.v1.QuestionDetails.parts:type_name -> pdfpagedata.v1.QuestionDetails
+	7,  // 15: pdfpagedata.v1.QuestionDetails.marking:type_name -> pdfpagedata.v1.MarkingAction
+	7,  // 16: pdfpagedata.v1.QuestionDetails.moderating:type_name -> pdfpagedata.v1.MarkingAction
+	7,  // 17: pdfpagedata.v1.QuestionDetails.checking:type_name -> pdfpagedata.v1.MarkingAction
+	15, // 18: pdfpagedata.v1.QuestionDetails.hlc:type_name -> pdfpagedata.v1.HLC
+	9,  // 19: pdfpagedata.v1.QuestionDetails.rubric:type_name -> pdfpagedata.v1.CriterionDetails
+	22, // 20: pdfpagedata.v1.QuestionDetails.extra:type_name -> pdfpagedata.v1.QuestionDetails.ExtraEntry
+	5,  // 21: pdfpagedata.v1.MarkingAction.contact:type_name -> pdfpagedata.v1.ContactDetails
+	8,  // 22: pdfpagedata.v1.MarkingAction.mark:type_name -> pdfpagedata.v1.MarkDetails
+	12, // 23: pdfpagedata.v1.MarkingAction.custom:type_name -> pdfpagedata.v1.CustomDetails
+	15, // 24: pdfpagedata.v1.MarkingAction.hlc:type_name -> pdfpagedata.v1.HLC
+	11, // 25: pdfpagedata.v1.MarkingAction.criteria:type_name -> pdfpagedata.v1.CriterionAward
+	23, // 26: pdfpagedata.v1.MarkingAction.extra:type_name -> pdfpagedata.v1.MarkingAction.ExtraEntry
+	24, // 27: pdfpagedata.v1.MarkDetails.extra:type_name -> pdfpagedata.v1.MarkDetails.ExtraEntry
+	10, // 28: pdfpagedata.v1.CriterionDetails.bands:type_name -> pdfpagedata.v1.BandDetails
+	25, // 29: pdfpagedata.v1.CriterionDetails.extra:type_name -> pdfpagedata.v1.CriterionDetails.ExtraEntry
+	26, // 30: pdfpagedata.v1.BandDetails.extra:type_name -> pdfpagedata.v1.BandDetails.ExtraEntry
+	27, // 31: pdfpagedata.v1.CriterionAward.extra:type_name -> pdfpagedata.v1.CriterionAward.ExtraEntry
+	28, // 32: pdfpagedata.v1.CustomDetails.extra:type_name -> pdfpagedata.v1.CustomDetails.ExtraEntry
+	14, // 33: pdfpagedata.v1.ProcessingDetails.parameters:type_name -> pdfpagedata.v1.ParameterDetails
+	5,  // 34: pdfpagedata.v1.ProcessingDetails.by:type_name -> pdfpagedata.v1.ContactDetails
+	15, // 35: pdfpagedata.v1.ProcessingDetails.hlc:type_name -> pdfpagedata.v1.HLC
+	29, // 36: pdfpagedata.v1.ProcessingDetails.extra:type_name -> pdfpagedata.v1.ProcessingDetails.ExtraEntry
+	30, // 37: pdfpagedata.v1.ParameterDetails.extra:type_name -> pdfpagedata.v1.ParameterDetails.ExtraEntry
+	31, // 38: pdfpagedata.v1.HLC.extra:type_name -> pdfpagedata.v1.HLC.ExtraEntry
+	39, // [39:39] is the sub-list for method output_type
+	39, // [39:39] is the sub-list for method input_type
+	39, // [39:39] is the sub-list for extension type_name
+	39, // [39:39] is the sub-list for extension extendee
+	0,  // [0:39] is the sub-list for field type_name
+}
+
+func init() { file_pagedatapb_pagedata_proto_init() }
+func file_pagedatapb_pagedata_proto_init() {
+	if File_pagedatapb_pagedata_proto != nil {
+		return
+	}
+	file_pagedatapb_pagedata_proto_msgTypes[11].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pagedatapb_pagedata_proto_rawDesc), len(file_pagedatapb_pagedata_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   32,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pagedatapb_pagedata_proto_goTypes,
+		DependencyIndexes: file_pagedatapb_pagedata_proto_depIdxs,
+		MessageInfos:      file_pagedatapb_pagedata_proto_msgTypes,
+	}.Build()
+	File_pagedatapb_pagedata_proto = out.File
+	file_pagedatapb_pagedata_proto_goTypes = nil
+	file_pagedatapb_pagedata_proto_depIdxs = nil
+}
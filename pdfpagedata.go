@@ -1,12 +1,11 @@
 package pdfpagedata
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand"
 	"os"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/timdrysdale/unipdf/v3/creator"
@@ -17,7 +16,8 @@ import (
 // UnixTime is not all that portable as it is ...
 // so only using it as a tiebreaker
 // when likely that the process was repeated on the
-// same machine
+// same machine (records stamped with an HLC are
+// ordered by that instead, see hlc.go)
 // For cases where marking is repeated after checking
 // The overlay SHOULD just use a higher sequence number,
 // sequence number is a step in sequence, not another
@@ -36,34 +36,74 @@ type PdfSummary struct {
 	CourseCode  string
 	PreparedFor string
 	ToDo        string
+	State       int         // least advanced of the pages, in DefaultWorkflow, see workflow.go
+	Found       bool        // if no page has page data, the rest is empty
+	PageStates  map[int]int // by page, counting from zero, for pages with page data
 }
 
+// TriagePdf summarises the page data in a file. The course code and
+// the like come from the first page that has page data, and the state
+// is that of the page furthest behind, so a file is only Checked once
+// every page in it is. Tokens that are left out, as suspect or not
+// decoding, are reported in a *DecodeError, along with the summary.
 func TriagePdf(inputPath string) (PdfSummary, error) {
 
-	pdfs := PdfSummary{}
-
 	pdm, err := GetPageDataFromFile(inputPath)
-	if err != nil {
-		return pdfs, err
+	if _, partial := err.(*DecodeError); err != nil && !partial {
+		return PdfSummary{}, err
 	}
 
-	err = PruneOldRevisions(&pdm)
-	if err != nil {
-		return pdfs, err
+	pdfs, terr := triage(pdm)
+	if terr != nil {
+		return pdfs, terr
 	}
 
-OUTER:
-	for _, v := range pdm {
-		for _, pd := range v {
+	return pdfs, err
+}
+
+func triage(pdm map[int][]PageData) (PdfSummary, error) {
+
+	pdfs := PdfSummary{}
+
+	var pages []int
+	for page, pds := range pdm {
+		if len(pds) > 0 {
+			pages = append(pages, page)
+		}
+	}
+
+	sort.Ints(pages)
+
+	for _, page := range pages {
+
+		pd, err := SelectPageDataByRevision(pdm[page])
+		if err != nil {
+			return pdfs, err
+		}
+
+		state := DefaultWorkflow.State(pd)
+
+		if !pdfs.Found {
 			pdfs.PreparedFor = pd.PreparedFor
 			pdfs.ToDo = pd.ToDo
 			pdfs.CourseCode = pd.Exam.CourseCode
-			break OUTER
+			pdfs.State = state
+			pdfs.Found = true
+			pdfs.PageStates = make(map[int]int)
 		}
+
+		if state < pdfs.State {
+			pdfs.State = state
+		}
+
+		pdfs.PageStates[page] = state
 	}
+
 	return pdfs, nil
 }
 
+// PruneOldRevisions keeps only the latest revision of each page; use
+// Histories to keep them all
 func PruneOldRevisions(pdmap *map[int][]PageData) error {
 	for k, v := range *pdmap {
 		pd, err := SelectPageDataByRevision(v)
@@ -75,6 +115,9 @@ func PruneOldRevisions(pdmap *map[int][]PageData) error {
 	return nil
 }
 
+// SelectPageDataByRevision returns the highest revision. Ties are broken
+// the same way as in History, so the choice doesn't depend on the order
+// the records were read in.
 func SelectPageDataByRevision(pds []PageData) (PageData, error) {
 	if len(pds) < 1 {
 		return PageData{}, errors.New("empty")
@@ -83,12 +126,7 @@ func SelectPageDataByRevision(pds []PageData) (PageData, error) {
 		return pds[0], nil
 	}
 
-	sort.SliceStable(pds, func(i, j int) bool {
-		return pds[i].Revision > pds[j].Revision
-
-	})
-
-	return pds[0], nil
+	return NewHistory(pds).Latest()
 }
 func SelectQuestionByLast(pd PageData) (QuestionDetails, error) {
 	if len(pd.Questions) < 1 {
@@ -99,17 +137,18 @@ func SelectQuestionByLast(pd PageData) (QuestionDetails, error) {
 	}
 
 	Q := pd.Questions
+	useHLC := allStamped(len(Q), func(i int) *HLC { return Q[i].HLC })
 	sort.SliceStable(Q, func(i, j int) bool {
-		if Q[i].Sequence == Q[j].Sequence {
-			return Q[i].UnixTime > Q[j].UnixTime
-		} else {
-			return Q[i].Sequence > Q[j].Sequence
+		if useHLC {
+			return Q[i].HLC.Compare(*Q[j].HLC) > 0
 		}
+		return compareLegacy(Q[i].Sequence, Q[j].Sequence, Q[i].UnixTime, Q[j].UnixTime) > 0
 	})
 
 	return Q[0], nil
 }
 
+// SelectProcessByLast is the latest step, ordered as lastStep orders them
 func SelectProcessByLast(pd PageData) (ProcessingDetails, error) {
 	if len(pd.Processing) < 1 {
 		return ProcessingDetails{}, errors.New("empty")
@@ -118,17 +157,52 @@ func SelectProcessByLast(pd PageData) (ProcessingDetails, error) {
 		return pd.Processing[0], nil
 	}
 
-	Process := pd.Processing
-	sort.SliceStable(Process, func(i, j int) bool {
-		if Process[i].Sequence == Process[j].Sequence {
-			return Process[i].UnixTime > Process[j].UnixTime
-		} else {
-			return Process[i].Sequence > Process[j].Sequence
+	last, _ := lastStep(pd.Processing, nil)
+
+	return pd.Processing[last], nil
+
+}
+
+// lastStep is the index of the last of the steps that include accepts
+// (all of them, if nil), by HLC if they all have one, else by sequence,
+// as renumbered by any merge, then time. Of steps that tie, the first is
+// last.
+func lastStep(steps []ProcessingDetails, include func(ProcessingDetails) bool) (int, bool) {
+
+	seqs := StepSequences(steps)
+
+	var included []int
+	for i, step := range steps {
+		if include == nil || include(step) {
+			included = append(included, i)
 		}
-	})
+	}
+
+	if len(included) == 0 {
+		return 0, false
+	}
+
+	useHLC := allStamped(len(included), func(i int) *HLC { return steps[included[i]].HLC })
+
+	last := included[0]
+	for _, i := range included[1:] {
+		if compareSteps(steps[i], steps[last], seqs[i], seqs[last], useHLC) > 0 {
+			last = i
+		}
+	}
+
+	return last, true
+}
+
+// compareSteps orders two steps by HLC, else by the sequence numbers
+// given for them, then time
+func compareSteps(a, b ProcessingDetails, seqA, seqB int, useHLC bool) int {
 
-	return Process[0], nil
+	if useHLC {
+		return a.HLC.Compare(*b.HLC)
+	}
 
+	return compareLegacy(seqA, seqB, a.UnixTime, b.UnixTime)
 }
 
 func GetLen(input map[int][]PageData) int {
@@ -141,55 +215,175 @@ func GetLen(input map[int][]PageData) int {
 	return items
 }
 
+// ReadOptions control how page data is read from a file
+type ReadOptions struct {
+	// Index is used to recover page data from the QR code or
+	// footer short code on pages that have lost their hidden
+	// text, e.g. after print-and-scan. Not used if nil.
+	Index *PageIndex
+
+	// Limits protect against hostile files. DefaultLimits()
+	// are used if nil; use &Limits{} for no limits at all.
+	Limits *Limits
+
+	// Strict rejects records with fields this version doesn't
+	// know about, instead of keeping them for round-trip
+	Strict bool
+}
+
+// DecodeError lists the tokens that were left out of a file's page data,
+// and why: either they could not be decoded, e.g. a record with a mark
+// that isn't a number, or they were suspect (ErrSuspectToken). So a
+// page's data is never lost without trace.
+type DecodeError struct {
+	Pages  []int // counting from zero
+	Tokens []string
+	Errs   []error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%d page data token(s) left out, the first on page %d: %v", len(e.Tokens), e.Pages[0], e.Errs[0])
+}
+
+func (e *DecodeError) add(page int, token string, err error) {
+	e.Pages = append(e.Pages, page)
+	e.Tokens = append(e.Tokens, token)
+	e.Errs = append(e.Errs, err)
+}
+
 func GetPageDataFromFile(inputPath string) (map[int][]PageData, error) {
+	return GetPageDataFromFileWithOptions(inputPath, ReadOptions{})
+}
+
+// GetPageDataFromFileWithOptions reads the page data on every page of
+// a file. Tokens that can't be decoded, or are suspect, are left out, and
+// reported in a *DecodeError, which comes with the rest of the records;
+// callers that can work with what was read should carry on with them.
+func GetPageDataFromFileWithOptions(inputPath string, opts ReadOptions) (map[int][]PageData, error) {
 
 	docData := make(map[int][]PageData)
+	undecoded := &DecodeError{}
 
-	texts, err := OutputPdfText(inputPath)
+	f, err := os.Open(inputPath)
 	if err != nil {
 		return docData, err
 	}
 
-	//one text per page
-	for i, text := range texts {
+	defer f.Close()
+
+	pdfReader, err := pdf.NewPdfReader(f)
+	if err != nil {
+		return docData, err
+	}
+
+	numPages, err := pdfReader.GetNumPages()
+	if err != nil {
+		return docData, err
+	}
+
+	limits := opts.limits()
+
+	err = checkPageCount(numPages, limits)
+	if err != nil {
+		return docData, err
+	}
+
+	total := 0
+
+	for i := 0; i < numPages; i++ {
+
+		page, err := pdfReader.GetPage(i + 1)
+		if err != nil {
+			return docData, err
+		}
+
+		text, err := readPageString(page, limits, i)
+		if err != nil {
+			return docData, err
+		}
+
 		var pds []PageData
 
-		strs := ExtractPageData(text)
+		strs, err := ExtractPageDataChecked(text)
+		if suspect, ok := err.(*SuspectTokenError); ok {
+			for _, token := range suspect.Tokens {
+				undecoded.add(i, token, ErrSuspectToken)
+			}
+		}
+
+		err = checkTokens(strs, limits, i, &total)
+		if err != nil {
+			return docData, err
+		}
 
 		for _, str := range strs {
-			var pd PageData
 
-			if err := json.Unmarshal([]byte(str), &pd); err != nil {
+			pd, err := DecodePageData(str, opts)
+
+			if le, ok := err.(*LimitError); ok {
+				le.Page = i
+				return docData, le
+			}
+
+			if _, ok := err.(*UnknownFieldsError); ok {
+				return docData, err
+			}
+
+			if err != nil {
+				undecoded.add(i, str, err)
 				continue
 			}
 
 			pds = append(pds, pd)
 		}
 
+		if len(pds) == 0 && opts.Index != nil {
+			pds = RecoverPageData(page, text, opts.Index)
+		}
+
 		docData[i] = pds
 	}
 
+	if len(undecoded.Tokens) > 0 {
+		return docData, undecoded
+	}
+
 	return docData, nil
 
 }
 
 func UnmarshalPageData(page *pdf.PdfPage) ([]PageData, error) {
+	return UnmarshalPageDataWithOptions(page, ReadOptions{})
+}
+
+// UnmarshalPageDataWithOptions reads the page data on one page, within
+// the limits in opts. Suspect tokens and those that don't decode are
+// reported, but the good records are still returned.
+func UnmarshalPageDataWithOptions(page *pdf.PdfPage, opts ReadOptions) ([]PageData, error) {
 
 	pageDatas := []PageData{}
 
-	tokens, err := ReadPageData(page)
+	limits := opts.limits()
+
+	text, err := readPageString(page, limits, 0)
 
 	if err != nil {
 		return pageDatas, err
 	}
 
-	var lastError error
+	// report suspect tokens, but still return the good ones
+	tokens, lastError := ExtractPageDataChecked(text)
 
-	for _, token := range tokens {
+	total := 0
+	err = checkTokens(tokens, limits, 0, &total)
+	if err != nil {
+		return pageDatas, err
+	}
 
-		var pd PageData
+	for _, token := range tokens {
 
-		if err := json.Unmarshal([]byte(token), &pd); err != nil {
+		pd, err := DecodePageData(token, opts)
+		if err != nil {
 			lastError = err
 			continue
 		}
@@ -198,66 +392,81 @@ func UnmarshalPageData(page *pdf.PdfPage) ([]PageData, error) {
 
 	}
 
+	if len(pageDatas) == 0 && opts.Index != nil {
+		pageDatas = append(pageDatas, RecoverPageData(page, text, opts.Index)...)
+	}
+
 	return pageDatas, lastError
 
 }
 
+// WriteOptions control how page data is written
+type WriteOptions struct {
+	// Codec defaults to JSON if nil
+	Codec Codec
+	// Validate refuses to write a record with errors, returning
+	// a *ValidationError; warnings are allowed through
+	Validate bool
+}
+
 func MarshalPageData(c *creator.Creator, pd *PageData) error {
+	return MarshalPageDataWithOptions(c, pd, WriteOptions{})
+}
+
+func MarshalPageDataWithOptions(c *creator.Creator, pd *PageData, opts WriteOptions) error {
 
-	token, err := json.Marshal(pd)
+	if opts.Validate {
+		if problems := Validate(*pd); HasErrors(problems) {
+			return &ValidationError{Problems: problems}
+		}
+	}
+
+	token, err := EncodeToken(pd, opts.Codec)
 	if err != nil {
 		return err
 	}
 
-	WritePageData(c, string(token))
+	WritePageData(c, token)
 
 	return nil
 
 }
 
 func ReadPageData(page *pdf.PdfPage) ([]string, error) {
-
-	text, err := ReadPageString(page)
-
-	if err != nil {
-		return []string{text}, err
-	}
-
-	return ExtractPageData(text), nil
-
+	return ReadNamespaceData(page, DefaultNamespace)
 }
 
 func ExtractPageData(pageText string) []string {
+	return ExtractNamespaceData(pageText, DefaultNamespace)
+}
 
-	var tokens []string
-
-LOOP:
-	for {
-
-		startIndex := strings.Index(pageText, StartTag)
-		if startIndex < 0 {
-			break LOOP
-		}
-
-		endIndex := strings.Index(pageText, EndTag)
-		if endIndex < 0 {
-			break LOOP
-		}
-
-		token := pageText[startIndex+StartTagOffset : endIndex]
-
-		tokens = append(tokens, token)
+// ReadPageString extracts the text from the page, refusing pages whose
+// content is larger than DefaultLimits allow
+func ReadPageString(page *pdf.PdfPage) (string, error) {
+	return readPageString(page, DefaultLimits(), -1)
+}
 
-		pageText = pageText[endIndex+EndTagOffset : len(pageText)]
+// readPageString checks the size of the page's content before
+// decoding it, and again before extracting text from it, since
+// that is slow and uses memory in proportion
+func readPageString(page *pdf.PdfPage, limits Limits, pageIndex int) (string, error) {
 
+	err := checkContentStreams(page, limits, pageIndex)
+	if err != nil {
+		return "", err
 	}
 
-	return tokens
-}
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return "", err
+	}
 
-func ReadPageString(page *pdf.PdfPage) (string, error) {
+	err = checkContent(contents, limits, pageIndex)
+	if err != nil {
+		return "", err
+	}
 
-	ex, err := extractor.New(page)
+	ex, err := extractor.NewFromContents(contents, page.Resources)
 	if err != nil {
 		return "", err
 	}
@@ -267,17 +476,26 @@ func ReadPageString(page *pdf.PdfPage) (string, error) {
 }
 
 func WritePageData(c *creator.Creator, text string) {
-	WritePageString(c, StartTag+text+EndTag)
+	WritePageString(c, StartTag+EscapeToken(text)+EndTag)
 }
 
 func WritePageString(c *creator.Creator, text string) {
 	p := c.NewParagraph(text)
-	p.SetFontSize(0.000001)
+	p.SetFontSize(hiddenFontSize)
+	x, y := hiddenPosition()
+	p.SetPos(x, y)
+	c.Draw(p)
+}
+
+const hiddenFontSize = 0.000001
+
+// hiddenPosition is a random location well off the page,
+// so that tokens on the same page don't overlap
+func hiddenPosition() (float64, float64) {
 	rand.Seed(time.Now().UnixNano())
 	x := rand.Float64()*0.1 + 99999 //0.3
 	y := rand.Float64()*999 + 99999 //0.3
-	p.SetPos(x, y)
-	c.Draw(p)
+	return x, y
 }
 
 // this function is for use in a co-operative
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -850,3 +851,87 @@ func PrettyPrintStruct(layout interface{}) error {
 	fmt.Println(string(json))
 	return nil
 }
+
+func TestGetPageDataFromFileBadMark(t *testing.T) {
+
+	pd := PageData{
+		Exam:       ExamDetails{CourseCode: "ENGI12123"},
+		Page:       PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c", Number: 1},
+		Processing: []ProcessingDetails{ProcessingDetails{UUID: "5f0b4bd6-8c0e-4b1f-9a57-3b0f4d3d1b7e", Name: "split"}},
+	}
+	bad := `{"exam":{"courseCode":"ENGI12123"},"questions":[{"name":"Q1","marksAwarded":"lots"}]}`
+
+	c := creator.New()
+	c.NewPage()
+	assert.NoError(t, MarshalPageData(c, &pd))
+	WritePageData(c, bad)
+	c.NewPage()
+	WritePageData(c, bad)
+
+	dir, err := os.MkdirTemp("", "pdfpagedata")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	inputPath := filepath.Join(dir, "bad.pdf")
+	assert.NoError(t, c.WriteToFile(inputPath))
+
+	// the good record is still read, and the bad ones are reported
+	pdm, err := GetPageDataFromFile(inputPath)
+	if assert.Equal(t, 1, len(pdm[0])) {
+		assert.Equal(t, "ENGI12123", pdm[0][0].Exam.CourseCode)
+	}
+	assert.Equal(t, 0, len(pdm[1]))
+
+	de, ok := err.(*DecodeError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []int{0, 1}, de.Pages)
+		assert.Equal(t, []string{bad, bad}, de.Tokens)
+		assert.Contains(t, de.Error(), "mark")
+	}
+
+	// and those reading the file carry on with the good records
+	summary, err := TriagePdf(inputPath)
+	assert.IsType(t, &DecodeError{}, err)
+	assert.True(t, summary.Found)
+	assert.Equal(t, "ENGI12123", summary.CourseCode)
+
+	index := NewPageIndex()
+	assert.IsType(t, &DecodeError{}, index.AddFile(inputPath))
+	assert.Equal(t, 1, index.Len())
+
+	prov := NewProvenance()
+	assert.IsType(t, &DecodeError{}, prov.AddFile(inputPath))
+	assert.Equal(t, 1, len(prov.Graph().Nodes))
+}
+
+func TestGetPageDataFromFileSuspect(t *testing.T) {
+
+	honest := `{"exam":{"courseCode":"ENGI12123"}}`
+
+	// as written by a tool that didn't escape a hostile filename
+	c := creator.New()
+	c.NewPage()
+	WritePageData(c, honest)
+	WritePageString(c, StartTag+`{"submission":{"originalFilename":"x`+EndTag+StartTag+forged+EndTag+StartTag+`y"}}`+EndTag)
+
+	dir, err := os.MkdirTemp("", "pdfpagedata")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	inputPath := filepath.Join(dir, "suspect.pdf")
+	assert.NoError(t, c.WriteToFile(inputPath))
+
+	pdm, err := GetPageDataFromFile(inputPath)
+	if assert.Equal(t, 1, len(pdm[0])) {
+		assert.Equal(t, "ENGI12123", pdm[0][0].Exam.CourseCode)
+	}
+
+	de, ok := err.(*DecodeError)
+	if assert.True(t, ok) {
+		assert.Equal(t, []int{0, 0, 0}, de.Pages)
+		assert.Contains(t, de.Tokens, forged)
+		for _, err := range de.Errs {
+			assert.Equal(t, ErrSuspectToken, err)
+		}
+	}
+}
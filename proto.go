@@ -0,0 +1,520 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+
+	"github.com/timdrysdale/pdfpagedata/pagedatapb"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative pagedatapb/pagedata.proto
+
+// ToProto converts pd to its protocol buffers message, for services that
+// would rather not deal with the JSON. Nothing is lost, including fields
+// held in Extra and marks, which are carried exactly in thousandths.
+// Proto can't tell an empty list from a nil one, so FromProto always
+// gives nil for an empty list, but the two are written the same in JSON,
+// so the record's hash is unchanged.
+func ToProto(pd PageData) *pagedatapb.PageData {
+
+	p := &pagedatapb.PageData{
+		Exam:        examToProto(pd.Exam),
+		Author:      authorToProto(pd.Author),
+		Page:        pageToProto(pd.Page),
+		Contact:     contactToProto(pd.Contact),
+		Submission:  submissionToProto(pd.Submission),
+		Revision:    int64(pd.Revision),
+		PreparedFor: pd.PreparedFor,
+		ToDo:        pd.ToDo,
+		Extra:       extraToProto(pd.Extra),
+	}
+
+	for _, q := range pd.Questions {
+		p.Questions = append(p.Questions, questionToProto(q))
+	}
+
+	for _, pr := range pd.Processing {
+		p.Processing = append(p.Processing, processingToProto(pr))
+	}
+
+	for _, c := range pd.Custom {
+		p.Custom = append(p.Custom, customToProto(c))
+	}
+
+	return p
+}
+
+// FromProto converts a protocol buffers message back to PageData.
+// Missing messages become zero values.
+func FromProto(p *pagedatapb.PageData) PageData {
+
+	pd := PageData{
+		Exam:        examFromProto(p.GetExam()),
+		Author:      authorFromProto(p.GetAuthor()),
+		Page:        pageFromProto(p.GetPage()),
+		Contact:     contactFromProto(p.GetContact()),
+		Submission:  submissionFromProto(p.GetSubmission()),
+		Revision:    int(p.GetRevision()),
+		PreparedFor: p.GetPreparedFor(),
+		ToDo:        p.GetToDo(),
+		Extra:       extraFromProto(p.GetExtra()),
+	}
+
+	for _, q := range p.GetQuestions() {
+		pd.Questions = append(pd.Questions, questionFromProto(q))
+	}
+
+	for _, pr := range p.GetProcessing() {
+		pd.Processing = append(pd.Processing, processingFromProto(pr))
+	}
+
+	for _, c := range p.GetCustom() {
+		pd.Custom = append(pd.Custom, customFromProto(c))
+	}
+
+	return pd
+}
+
+// extra fields are kept as their raw JSON text
+func extraToProto(extra map[string]json.RawMessage) map[string]string {
+
+	if len(extra) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(extra))
+	for k, v := range extra {
+		m[k] = string(v)
+	}
+
+	return m
+}
+
+func extraFromProto(m map[string]string) map[string]json.RawMessage {
+
+	if len(m) == 0 {
+		return nil
+	}
+
+	extra := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		extra[k] = json.RawMessage(v)
+	}
+
+	return extra
+}
+
+func submissionToProto(s SubmissionDetails) *pagedatapb.SubmissionDetails {
+	return &pagedatapb.SubmissionDetails{
+		FilePrefix:       s.FilePrefix,
+		OriginalFilename: s.OriginalFilename,
+		OriginalFormat:   s.OriginalFormat,
+		NewFilename:      s.NewFilename,
+		NewFormat:        s.NewFormat,
+		Extra:            extraToProto(s.Extra),
+	}
+}
+
+func submissionFromProto(p *pagedatapb.SubmissionDetails) SubmissionDetails {
+	return SubmissionDetails{
+		FilePrefix:       p.GetFilePrefix(),
+		OriginalFilename: p.GetOriginalFilename(),
+		OriginalFormat:   p.GetOriginalFormat(),
+		NewFilename:      p.GetNewFilename(),
+		NewFormat:        p.GetNewFormat(),
+		Extra:            extraFromProto(p.GetExtra()),
+	}
+}
+
+func examToProto(e ExamDetails) *pagedatapb.ExamDetails {
+	return &pagedatapb.ExamDetails{
+		CourseCode: e.CourseCode,
+		Diet:       e.Diet,
+		Date:       e.Date,
+		Uuid:       e.UUID,
+		Extra:      extraToProto(e.Extra),
+	}
+}
+
+func examFromProto(p *pagedatapb.ExamDetails) ExamDetails {
+	return ExamDetails{
+		CourseCode: p.GetCourseCode(),
+		Diet:       p.GetDiet(),
+		Date:       p.GetDate(),
+		UUID:       p.GetUuid(),
+		Extra:      extraFromProto(p.GetExtra()),
+	}
+}
+
+func authorToProto(a AuthorDetails) *pagedatapb.AuthorDetails {
+	return &pagedatapb.AuthorDetails{
+		Anonymous: a.Anonymous,
+		Identity:  a.Identity,
+		Extra:     extraToProto(a.Extra),
+	}
+}
+
+func authorFromProto(p *pagedatapb.AuthorDetails) AuthorDetails {
+	return AuthorDetails{
+		Anonymous: p.GetAnonymous(),
+		Identity:  p.GetIdentity(),
+		Extra:     extraFromProto(p.GetExtra()),
+	}
+}
+
+func pageToProto(pg PageDetails) *pagedatapb.PageDetails {
+	return &pagedatapb.PageDetails{
+		Uuid:     pg.UUID,
+		Number:   int64(pg.Number),
+		Of:       int64(pg.Of),
+		Filename: pg.Filename,
+		Extra:    extraToProto(pg.Extra),
+	}
+}
+
+func pageFromProto(p *pagedatapb.PageDetails) PageDetails {
+	return PageDetails{
+		UUID:     p.GetUuid(),
+		Number:   int(p.GetNumber()),
+		Of:       int(p.GetOf()),
+		Filename: p.GetFilename(),
+		Extra:    extraFromProto(p.GetExtra()),
+	}
+}
+
+func contactToProto(c ContactDetails) *pagedatapb.ContactDetails {
+	return &pagedatapb.ContactDetails{
+		Name:    c.Name,
+		Uuid:    c.UUID,
+		Email:   c.Email,
+		Address: c.Address,
+		Extra:   extraToProto(c.Extra),
+	}
+}
+
+func contactFromProto(p *pagedatapb.ContactDetails) ContactDetails {
+	return ContactDetails{
+		Name:    p.GetName(),
+		UUID:    p.GetUuid(),
+		Email:   p.GetEmail(),
+		Address: p.GetAddress(),
+		Extra:   extraFromProto(p.GetExtra()),
+	}
+}
+
+func questionToProto(q QuestionDetails) *pagedatapb.QuestionDetails {
+
+	p := &pagedatapb.QuestionDetails{
+		Uuid:                      q.UUID,
+		Name:                      q.Name,
+		Section:                   q.Section,
+		Number:                    int64(q.Number),
+		MarksAvailableThousandths: q.MarksAvailable.Thousandths(),
+		MarksAwardedThousandths:   q.MarksAwarded.Thousandths(),
+		Sequence:                  int64(q.Sequence),
+		UnixTime:                  q.UnixTime,
+		Previous:                  q.Previous,
+		PreviousHash:              q.PreviousHash,
+		Hlc:                       hlcToProto(q.HLC),
+		Extra:                     extraToProto(q.Extra),
+	}
+
+	for _, part := range q.Parts {
+		p.Parts = append(p.Parts, questionToProto(part))
+	}
+
+	p.Marking = actionsToProto(q.Marking)
+	p.Moderating = actionsToProto(q.Moderating)
+	p.Checking = actionsToProto(q.Checking)
+
+	for _, c := range q.Rubric {
+		p.Rubric = append(p.Rubric, criterionToProto(c))
+	}
+
+	return p
+}
+
+func questionFromProto(p *pagedatapb.QuestionDetails) QuestionDetails {
+
+	q := QuestionDetails{
+		UUID:           p.GetUuid(),
+		Name:           p.GetName(),
+		Section:        p.GetSection(),
+		Number:         int(p.GetNumber()),
+		MarksAvailable: MarkThousandths(p.GetMarksAvailableThousandths()),
+		MarksAwarded:   MarkThousandths(p.GetMarksAwardedThousandths()),
+		Sequence:       int(p.GetSequence()),
+		UnixTime:       p.GetUnixTime(),
+		Previous:       p.GetPrevious(),
+		PreviousHash:   p.GetPreviousHash(),
+		HLC:            hlcFromProto(p.GetHlc()),
+		Extra:          extraFromProto(p.GetExtra()),
+	}
+
+	for _, part := range p.GetParts() {
+		q.Parts = append(q.Parts, questionFromProto(part))
+	}
+
+	q.Marking = actionsFromProto(p.GetMarking())
+	q.Moderating = actionsFromProto(p.GetModerating())
+	q.Checking = actionsFromProto(p.GetChecking())
+
+	for _, c := range p.GetRubric() {
+		q.Rubric = append(q.Rubric, criterionFromProto(c))
+	}
+
+	return q
+}
+
+func actionsToProto(actions []MarkingAction) []*pagedatapb.MarkingAction {
+
+	var p []*pagedatapb.MarkingAction
+
+	for _, a := range actions {
+		p = append(p, &pagedatapb.MarkingAction{
+			Actor:        a.Actor,
+			Contact:      contactToProto(a.Contact),
+			Mark:         markToProto(a.Mark),
+			Done:         a.Done,
+			UnixTime:     a.UnixTime,
+			Custom:       customToProto(a.Custom),
+			Hlc:          hlcToProto(a.HLC),
+			Criteria:     awardsToProto(a.Criteria),
+			Uuid:         a.UUID,
+			Previous:     a.Previous,
+			PreviousHash: a.PreviousHash,
+			Extra:        extraToProto(a.Extra),
+		})
+	}
+
+	return p
+}
+
+func actionsFromProto(p []*pagedatapb.MarkingAction) []MarkingAction {
+
+	var actions []MarkingAction
+
+	for _, a := range p {
+		actions = append(actions, MarkingAction{
+			Actor:        a.GetActor(),
+			Contact:      contactFromProto(a.GetContact()),
+			Mark:         markFromProto(a.GetMark()),
+			Done:         a.GetDone(),
+			UnixTime:     a.GetUnixTime(),
+			Custom:       customFromProto(a.GetCustom()),
+			HLC:          hlcFromProto(a.GetHlc()),
+			Criteria:     awardsFromProto(a.GetCriteria()),
+			UUID:         a.GetUuid(),
+			Previous:     a.GetPrevious(),
+			PreviousHash: a.GetPreviousHash(),
+			Extra:        extraFromProto(a.GetExtra()),
+		})
+	}
+
+	return actions
+}
+
+func markToProto(m MarkDetails) *pagedatapb.MarkDetails {
+	return &pagedatapb.MarkDetails{
+		GivenThousandths:     m.Given.Thousandths(),
+		AvailableThousandths: m.Available.Thousandths(),
+		Comment:              m.Comment,
+		Extra:                extraToProto(m.Extra),
+	}
+}
+
+func markFromProto(p *pagedatapb.MarkDetails) MarkDetails {
+	return MarkDetails{
+		Given:     MarkThousandths(p.GetGivenThousandths()),
+		Available: MarkThousandths(p.GetAvailableThousandths()),
+		Comment:   p.GetComment(),
+		Extra:     extraFromProto(p.GetExtra()),
+	}
+}
+
+func criterionToProto(c CriterionDetails) *pagedatapb.CriterionDetails {
+
+	p := &pagedatapb.CriterionDetails{
+		Id:               c.ID,
+		Descriptor_:      c.Descriptor,
+		MarksThousandths: c.Marks.Thousandths(),
+		Extra:            extraToProto(c.Extra),
+	}
+
+	for _, b := range c.Bands {
+		p.Bands = append(p.Bands, &pagedatapb.BandDetails{
+			Id:             b.ID,
+			Descriptor_:    b.Descriptor,
+			MinThousandths: b.Min.Thousandths(),
+			MaxThousandths: b.Max.Thousandths(),
+			Extra:          extraToProto(b.Extra),
+		})
+	}
+
+	return p
+}
+
+func criterionFromProto(p *pagedatapb.CriterionDetails) CriterionDetails {
+
+	c := CriterionDetails{
+		ID:         p.GetId(),
+		Descriptor: p.GetDescriptor_(),
+		Marks:      MarkThousandths(p.GetMarksThousandths()),
+		Extra:      extraFromProto(p.GetExtra()),
+	}
+
+	for _, b := range p.GetBands() {
+		c.Bands = append(c.Bands, BandDetails{
+			ID:         b.GetId(),
+			Descriptor: b.GetDescriptor_(),
+			Min:        MarkThousandths(b.GetMinThousandths()),
+			Max:        MarkThousandths(b.GetMaxThousandths()),
+			Extra:      extraFromProto(b.GetExtra()),
+		})
+	}
+
+	return c
+}
+
+// a mark left out of an award stays left out, it doesn't become zero
+func awardsToProto(awards []CriterionAward) []*pagedatapb.CriterionAward {
+
+	var p []*pagedatapb.CriterionAward
+
+	for _, a := range awards {
+
+		pa := &pagedatapb.CriterionAward{
+			Criterion: a.Criterion,
+			Band:      a.Band,
+			Extra:     extraToProto(a.Extra),
+		}
+
+		if a.Mark != nil {
+			m := a.Mark.Thousandths()
+			pa.MarkThousandths = &m
+		}
+
+		p = append(p, pa)
+	}
+
+	return p
+}
+
+func awardsFromProto(p []*pagedatapb.CriterionAward) []CriterionAward {
+
+	var awards []CriterionAward
+
+	for _, pa := range p {
+
+		a := CriterionAward{
+			Criterion: pa.GetCriterion(),
+			Band:      pa.GetBand(),
+			Extra:     extraFromProto(pa.GetExtra()),
+		}
+
+		if pa.MarkThousandths != nil {
+			m := MarkThousandths(pa.GetMarkThousandths())
+			a.Mark = &m
+		}
+
+		awards = append(awards, a)
+	}
+
+	return awards
+}
+
+func customToProto(c CustomDetails) *pagedatapb.CustomDetails {
+	return &pagedatapb.CustomDetails{
+		Key:   c.Key,
+		Value: c.Value,
+		Extra: extraToProto(c.Extra),
+	}
+}
+
+func customFromProto(p *pagedatapb.CustomDetails) CustomDetails {
+	return CustomDetails{
+		Key:   p.GetKey(),
+		Value: p.GetValue(),
+		Extra: extraFromProto(p.GetExtra()),
+	}
+}
+
+func processingToProto(pr ProcessingDetails) *pagedatapb.ProcessingDetails {
+
+	p := &pagedatapb.ProcessingDetails{
+		Uuid:         pr.UUID,
+		Previous:     pr.Previous,
+		UnixTime:     pr.UnixTime,
+		Name:         pr.Name,
+		By:           contactToProto(pr.By),
+		Sequence:     int64(pr.Sequence),
+		Hlc:          hlcToProto(pr.HLC),
+		PreviousHash: pr.PreviousHash,
+		Extra:        extraToProto(pr.Extra),
+	}
+
+	for _, param := range pr.Parameters {
+		p.Parameters = append(p.Parameters, &pagedatapb.ParameterDetails{
+			Name:     param.Name,
+			Value:    param.Value,
+			Sequence: int64(param.Sequence),
+			Extra:    extraToProto(param.Extra),
+		})
+	}
+
+	return p
+}
+
+func processingFromProto(p *pagedatapb.ProcessingDetails) ProcessingDetails {
+
+	pr := ProcessingDetails{
+		UUID:         p.GetUuid(),
+		Previous:     p.GetPrevious(),
+		UnixTime:     p.GetUnixTime(),
+		Name:         p.GetName(),
+		By:           contactFromProto(p.GetBy()),
+		Sequence:     int(p.GetSequence()),
+		HLC:          hlcFromProto(p.GetHlc()),
+		PreviousHash: p.GetPreviousHash(),
+		Extra:        extraFromProto(p.GetExtra()),
+	}
+
+	for _, param := range p.GetParameters() {
+		pr.Parameters = append(pr.Parameters, ParameterDetails{
+			Name:     param.GetName(),
+			Value:    param.GetValue(),
+			Sequence: int(param.GetSequence()),
+			Extra:    extraFromProto(param.GetExtra()),
+		})
+	}
+
+	return pr
+}
+
+// a missing HLC stays missing, it doesn't become the zero time
+func hlcToProto(h *HLC) *pagedatapb.HLC {
+
+	if h == nil {
+		return nil
+	}
+
+	return &pagedatapb.HLC{
+		WallTime: h.WallTime,
+		Logical:  int64(h.Logical),
+		Node:     h.Node,
+		Extra:    extraToProto(h.Extra),
+	}
+}
+
+func hlcFromProto(p *pagedatapb.HLC) *HLC {
+
+	if p == nil {
+		return nil
+	}
+
+	return &HLC{
+		WallTime: p.GetWallTime(),
+		Logical:  int(p.GetLogical()),
+		Node:     p.GetNode(),
+		Extra:    extraFromProto(p.GetExtra()),
+	}
+}
@@ -0,0 +1,108 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/pdfpagedata/pagedatapb"
+	"google.golang.org/protobuf/proto"
+)
+
+func protoRoundTrip(t *testing.T, pd PageData) PageData {
+
+	data, err := proto.Marshal(ToProto(pd))
+	assert.NoError(t, err)
+
+	var p pagedatapb.PageData
+	assert.NoError(t, proto.Unmarshal(data, &p))
+
+	return FromProto(&p)
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+
+	pd := codecTestPageData()
+	pd.Questions[0].Parts = []QuestionDetails{
+		QuestionDetails{Name: "Q1a", Section: "a", MarksAwarded: NewMark(1.25)},
+	}
+	pd.Processing[0].Parameters = []ParameterDetails{
+		ParameterDetails{Name: "dpi", Value: "300", Sequence: 2},
+	}
+	pd.Custom = []CustomDetails{CustomDetails{Key: "k", Value: "v"}}
+
+	assert.Equal(t, pd, protoRoundTrip(t, pd))
+
+	// unknown fields come through as the same JSON
+	var unknown PageData
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &unknown))
+
+	out := protoRoundTrip(t, unknown)
+	assert.Equal(t, UnknownFields(unknown), UnknownFields(out))
+
+	a, err := Canonical(unknown)
+	assert.NoError(t, err)
+	b, err := Canonical(out)
+	assert.NoError(t, err)
+	assert.Equal(t, string(a), string(b))
+}
+
+func TestProtoKeepsHash(t *testing.T) {
+
+	// empty lists, as decoded from [], come back nil
+	var pd PageData
+	assert.NoError(t, json.Unmarshal([]byte(`{"questions":[{"parts":[],"markers":[{"mark":{"given":2.5}}],
+	  "moderators":[],"checkers":[]}],"processing":[{"parameters":[]}],"custom":[]}`), &pd))
+	assert.NotNil(t, pd.Custom)
+
+	out := protoRoundTrip(t, pd)
+	assert.Nil(t, out.Custom)
+
+	a, err := pd.Hash()
+	assert.NoError(t, err)
+	b, err := out.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	// and so do the links in the chain
+	a, err = StepHash(pd.Processing[0])
+	assert.NoError(t, err)
+	b, err = StepHash(out.Processing[0])
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	// marks are exact, even where a double isn't
+	big := MarkThousandths(9007199254740993)
+	pd.Questions[0].MarksAvailable = big
+	pd.Questions[0].Marking[0].Mark.Available = big
+	out = protoRoundTrip(t, pd)
+	assert.Equal(t, big, out.Questions[0].MarksAvailable)
+	assert.Equal(t, big, out.Questions[0].Marking[0].Mark.Available)
+}
+
+func TestFromProtoMissing(t *testing.T) {
+
+	assert.Equal(t, PageData{}, FromProto(&pagedatapb.PageData{}))
+	assert.Equal(t, PageData{}, FromProto(nil))
+}
+
+func TestProtoRubric(t *testing.T) {
+
+	q := rubricQuestion()
+	q.Marking = []MarkingAction{
+		MarkingAction{
+			UUID:         "00000000-0000-4000-8000-000000000002",
+			Previous:     "00000000-0000-4000-8000-000000000001",
+			PreviousHash: "ab12",
+			Mark:         MarkDetails{Given: NewMark(3.5)},
+			Criteria: []CriterionAward{
+				CriterionAward{Criterion: "method"},
+				CriterionAward{Criterion: "answer", Band: "weak", Mark: markPtr(1.5)},
+			},
+		},
+	}
+
+	pd := PageData{Questions: []QuestionDetails{q}}
+
+	assert.Equal(t, pd, FromProto(ToProto(pd)))
+}
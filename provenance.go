@@ -0,0 +1,303 @@
+package pdfpagedata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Provenance follows pages through their processing steps, across a
+// whole corpus of pdfs, for seeing afterwards how a page got to where it
+// is. Each step is a node, named by its UUID, with an edge from the step
+// it names as Previous, and, for a merge step, from the step it names as
+// its second parent. A step that is in many records (e.g. each revision
+// of a page, or each page of a split) is one node, annotated with every
+// page it was found on. Copies of a step should be identical; where they
+// disagree on its name or who did it, the first copy found is kept, and
+// the disagreement is noted in the node's Conflicts. A Previous that
+// isn't in the corpus is a node too, marked Missing, so that gaps show
+// up in the graph. Steps without a UUID can't be linked to, so are left
+// out.
+type Provenance struct {
+	nodes map[string]*ProvenanceNode
+	edges map[ProvenanceEdge]bool
+}
+
+// ProvenanceNode is a processing step
+type ProvenanceNode struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name,omitempty"`
+	By        string           `json:"by,omitempty"`
+	Sequence  int              `json:"sequence,omitempty"`
+	UnixTime  int64            `json:"unixTime,omitempty"`
+	HLC       *HLC             `json:"hlc,omitempty"`
+	Pages     []ProvenancePage `json:"pages,omitempty"`
+	Missing   bool             `json:"missing,omitempty"`
+	Conflicts []string         `json:"conflicts,omitempty"`
+}
+
+// ProvenancePage is a record that a step was found in
+type ProvenancePage struct {
+	UUID     string `json:"uuid"`
+	Filename string `json:"filename"`
+	Revision int    `json:"revision"`
+}
+
+// ProvenanceEdge goes from a step to the one that followed it
+type ProvenanceEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ProvenanceGraph is the node/edge list, in a stable order, ready to be
+// written as JSON
+type ProvenanceGraph struct {
+	Nodes []ProvenanceNode `json:"nodes"`
+	Edges []ProvenanceEdge `json:"edges"`
+}
+
+func NewProvenance() *Provenance {
+	return &Provenance{
+		nodes: make(map[string]*ProvenanceNode),
+		edges: make(map[ProvenanceEdge]bool),
+	}
+}
+
+// Add adds the processing steps of a record
+func (p *Provenance) Add(pd PageData) {
+
+	page := ProvenancePage{
+		UUID:     pd.Page.UUID,
+		Filename: pd.Page.Filename,
+		Revision: pd.Revision,
+	}
+
+	for _, step := range pd.Processing {
+
+		if step.UUID == "" {
+			continue
+		}
+
+		n := p.node(step.UUID)
+
+		if n.Missing {
+			n.Missing = false
+			n.Name = step.Name
+			n.By = step.By.Name
+			n.Sequence = step.Sequence
+			n.UnixTime = step.UnixTime
+			n.HLC = step.HLC
+		} else {
+			n.conflict("name", n.Name, step.Name, page)
+			n.conflict("by", n.By, step.By.Name, page)
+		}
+
+		n.addPage(page)
+
+		p.edge(step.Previous, step.UUID)
+
+		for _, param := range step.Parameters {
+			if param.Name == MergeParentParameter {
+				p.edge(param.Value, step.UUID)
+			}
+		}
+	}
+}
+
+func (p *Provenance) edge(from, to string) {
+
+	if from == "" {
+		return
+	}
+
+	p.node(from)
+	p.edges[ProvenanceEdge{From: from, To: to}] = true
+}
+
+// AddFile adds every record found in a pdf. Tokens that are left out,
+// as suspect or not decoding, are reported in a *DecodeError, once the
+// rest have been added.
+func (p *Provenance) AddFile(inputPath string) error {
+
+	pdm, err := GetPageDataFromFile(inputPath)
+	if _, partial := err.(*DecodeError); err != nil && !partial {
+		return err
+	}
+
+	for _, pds := range pdm {
+		for _, pd := range pds {
+			p.Add(pd)
+		}
+	}
+
+	return err
+}
+
+// node returns the node for a step, adding it as missing until the step
+// itself is added
+func (p *Provenance) node(id string) *ProvenanceNode {
+
+	n, ok := p.nodes[id]
+	if !ok {
+		n = &ProvenanceNode{ID: id, Missing: true}
+		p.nodes[id] = n
+	}
+
+	return n
+}
+
+// conflict notes a copy of the step that disagrees with the first found
+func (n *ProvenanceNode) conflict(field, have, got string, page ProvenancePage) {
+
+	if have == got {
+		return
+	}
+
+	first := n.Pages[0]
+	c := fmt.Sprintf("%s %q in %s r%d, but %q in %s r%d", field, have, first.Filename, first.Revision, got, page.Filename, page.Revision)
+
+	for _, existing := range n.Conflicts {
+		if existing == c {
+			return
+		}
+	}
+
+	n.Conflicts = append(n.Conflicts, c)
+}
+
+func (n *ProvenanceNode) addPage(page ProvenancePage) {
+
+	for _, existing := range n.Pages {
+		if existing == page {
+			return
+		}
+	}
+
+	n.Pages = append(n.Pages, page)
+}
+
+// Graph returns the nodes in order of HLC, if every step found has one,
+// else in time order, and the edges in the order of the nodes they come
+// from. Missing steps have no time, so come first.
+func (p *Provenance) Graph() ProvenanceGraph {
+
+	var g ProvenanceGraph
+	var stamps []*HLC
+
+	for _, n := range p.nodes {
+
+		node := *n
+		node.Pages = append([]ProvenancePage(nil), n.Pages...)
+		node.Conflicts = append([]string(nil), n.Conflicts...)
+
+		if !n.Missing {
+			stamps = append(stamps, n.HLC)
+		}
+
+		sort.Slice(node.Pages, func(i, j int) bool {
+			a, b := node.Pages[i], node.Pages[j]
+			if a.Filename != b.Filename {
+				return a.Filename < b.Filename
+			}
+			if a.UUID != b.UUID {
+				return a.UUID < b.UUID
+			}
+			return a.Revision < b.Revision
+		})
+
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	useHLC := allStamped(len(stamps), func(i int) *HLC { return stamps[i] })
+
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		a, b := g.Nodes[i], g.Nodes[j]
+		switch {
+		case useHLC && a.Missing != b.Missing:
+			return a.Missing
+		case useHLC && !a.Missing && a.HLC.Compare(*b.HLC) != 0:
+			return a.HLC.Compare(*b.HLC) < 0
+		case !useHLC && a.UnixTime != b.UnixTime:
+			return a.UnixTime < b.UnixTime
+		}
+		return a.ID < b.ID
+	})
+
+	position := make(map[string]int)
+	for i, n := range g.Nodes {
+		position[n.ID] = i
+	}
+
+	for e := range p.edges {
+		g.Edges = append(g.Edges, e)
+	}
+
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return position[a.From] < position[b.From]
+		}
+		return position[a.To] < position[b.To]
+	})
+
+	return g
+}
+
+// WriteDOT writes the graph in Graphviz's DOT language, e.g. to render
+// with `dot -Tsvg`. Each node is labelled with its step, who did it, and
+// the files and revisions it was found in; missing steps are dashed, and
+// steps whose copies disagree are red, with the disagreements listed.
+func (p *Provenance) WriteDOT(w io.Writer) error {
+	return p.Graph().WriteDOT(w)
+}
+
+func (g ProvenanceGraph) WriteDOT(w io.Writer) error {
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph provenance {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+
+	for _, n := range g.Nodes {
+
+		if n.Missing {
+			fmt.Fprintf(bw, "\t%s [label=%s, style=dashed];\n", dotQuote(n.ID), dotQuote("missing\n"+n.ID))
+			continue
+		}
+
+		lines := []string{n.Name}
+		if n.By != "" {
+			lines = append(lines, "by "+n.By)
+		}
+		for _, page := range n.Pages {
+			lines = append(lines, fmt.Sprintf("%s r%d", page.Filename, page.Revision))
+		}
+
+		if len(n.Conflicts) > 0 {
+			lines = append(lines, n.Conflicts...)
+			fmt.Fprintf(bw, "\t%s [label=%s, color=red];\n", dotQuote(n.ID), dotQuote(strings.Join(lines, "\n")))
+			continue
+		}
+
+		fmt.Fprintf(bw, "\t%s [label=%s];\n", dotQuote(n.ID), dotQuote(strings.Join(lines, "\n")))
+	}
+
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "\t%s -> %s;\n", dotQuote(e.From), dotQuote(e.To))
+	}
+
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// dotQuote makes a DOT string, with newlines as line breaks
+func dotQuote(s string) string {
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,172 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func provenanceCorpus() []PageData {
+
+	scan := ProcessingDetails{UUID: "s1", Name: "scan", By: ContactDetails{Name: "scanner"}, Sequence: 1, UnixTime: 10}
+
+	return []PageData{
+		PageData{
+			Page:       PageDetails{UUID: "p0", Filename: "scan.pdf"},
+			Processing: []ProcessingDetails{scan},
+		},
+		PageData{
+			Page:     PageDetails{UUID: "p1", Filename: "p1.pdf"},
+			Revision: 1,
+			Processing: []ProcessingDetails{
+				scan,
+				ProcessingDetails{UUID: "s2", Previous: "s1", Name: "split", By: ContactDetails{Name: "gradex"}, Sequence: 2, UnixTime: 20},
+			},
+		},
+		PageData{
+			Page:     PageDetails{UUID: "p2", Filename: "p2.pdf"},
+			Revision: 1,
+			Processing: []ProcessingDetails{
+				scan,
+				ProcessingDetails{UUID: "s3", Previous: "s1", Name: "split", By: ContactDetails{Name: "gradex"}, Sequence: 2, UnixTime: 21},
+				ProcessingDetails{UUID: "s4", Previous: "gone", Name: "mark", By: ContactDetails{Name: "marker"}, Sequence: 3, UnixTime: 30},
+				ProcessingDetails{Name: "no-uuid"},
+			},
+		},
+	}
+}
+
+func TestProvenanceGraph(t *testing.T) {
+
+	p := NewProvenance()
+	for _, pd := range provenanceCorpus() {
+		p.Add(pd)
+	}
+
+	g := p.Graph()
+
+	var ids []string
+	for _, n := range g.Nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.Equal(t, []string{"gone", "s1", "s2", "s3", "s4"}, ids)
+
+	assert.True(t, g.Nodes[0].Missing)
+	assert.False(t, g.Nodes[1].Missing)
+	assert.Equal(t, "scanner", g.Nodes[1].By)
+	assert.Equal(t, []ProvenancePage{
+		ProvenancePage{UUID: "p1", Filename: "p1.pdf", Revision: 1},
+		ProvenancePage{UUID: "p2", Filename: "p2.pdf", Revision: 1},
+		ProvenancePage{UUID: "p0", Filename: "scan.pdf", Revision: 0},
+	}, g.Nodes[1].Pages)
+
+	assert.Equal(t, []ProvenanceEdge{
+		ProvenanceEdge{From: "gone", To: "s4"},
+		ProvenanceEdge{From: "s1", To: "s2"},
+		ProvenanceEdge{From: "s1", To: "s3"},
+	}, g.Edges)
+}
+
+func TestProvenanceMerge(t *testing.T) {
+
+	by := ContactDetails{Name: "marker"}
+	clock := NewClock("node")
+
+	base := prefixUUIDs(NewBuilder(), 1).WithHLC(clock).Page(1, 1).Process("split", by).Build()
+	ours := prefixUUIDs(UpdateBuilder(base), 2).WithHLC(clock).Process("mark", by).Build()
+	theirs := prefixUUIDs(UpdateBuilder(base), 3).WithHLC(clock).Process("check", by).Build()
+
+	merged, _, err := MergeWithOptions(base, ours, theirs, MergeOptions{By: by, Clock: clock})
+	assert.NoError(t, err)
+
+	// wall times that disagree with the HLCs
+	merged.Processing[0].UnixTime = 40
+	merged.Processing[1].UnixTime = 30
+	merged.Processing[2].UnixTime = 20
+	merged.Processing[3].UnixTime = 10
+
+	p := NewProvenance()
+	p.Add(merged)
+
+	g := p.Graph()
+
+	var names []string
+	for _, n := range g.Nodes {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"split", "mark", "check", MergeStepName}, names)
+
+	step, mark, check := merged.Processing[3].UUID, merged.Processing[1].UUID, merged.Processing[2].UUID
+	assert.Contains(t, g.Edges, ProvenanceEdge{From: mark, To: step})
+	assert.Contains(t, g.Edges, ProvenanceEdge{From: check, To: step})
+}
+
+func TestProvenanceConflict(t *testing.T) {
+
+	corpus := provenanceCorpus()
+	corpus[2].Processing[0].Name = "rescan"
+
+	p := NewProvenance()
+	for _, pd := range corpus {
+		p.Add(pd)
+	}
+
+	g := p.Graph()
+
+	if assert.Equal(t, "s1", g.Nodes[1].ID) {
+		assert.Equal(t, "scan", g.Nodes[1].Name)
+		assert.Equal(t, []string{`name "scan" in scan.pdf r0, but "rescan" in p2.pdf r1`}, g.Nodes[1].Conflicts)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, g.WriteDOT(&buf))
+	assert.Contains(t, buf.String(), `"s1" [label="scan\nby scanner\np1.pdf r1\np2.pdf r1\nscan.pdf r0\nname \"scan\" in scan.pdf r0, but \"rescan\" in p2.pdf r1", color=red];`)
+}
+
+func TestProvenanceJSON(t *testing.T) {
+
+	p := NewProvenance()
+	p.Add(provenanceCorpus()[1])
+
+	data, err := json.Marshal(p.Graph())
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+	  "nodes":[
+	    {"id":"s1","name":"scan","by":"scanner","sequence":1,"unixTime":10,
+	     "pages":[{"uuid":"p1","filename":"p1.pdf","revision":1}]},
+	    {"id":"s2","name":"split","by":"gradex","sequence":2,"unixTime":20,
+	     "pages":[{"uuid":"p1","filename":"p1.pdf","revision":1}]}],
+	  "edges":[{"from":"s1","to":"s2"}]}`, string(data))
+}
+
+func TestProvenanceDOT(t *testing.T) {
+
+	p := NewProvenance()
+	for _, pd := range provenanceCorpus()[1:] {
+		p.Add(pd)
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, p.WriteDOT(&buf))
+
+	assert.Equal(t, `digraph provenance {
+	rankdir=LR;
+	node [shape=box];
+	"gone" [label="missing\ngone", style=dashed];
+	"s1" [label="scan\nby scanner\np1.pdf r1\np2.pdf r1"];
+	"s2" [label="split\nby gradex\np1.pdf r1"];
+	"s3" [label="split\nby gradex\np2.pdf r1"];
+	"s4" [label="mark\nby marker\np2.pdf r1"];
+	"gone" -> "s4";
+	"s1" -> "s2";
+	"s1" -> "s3";
+}
+`, buf.String())
+}
+
+func TestDotQuote(t *testing.T) {
+	assert.Equal(t, `"a \"b\"\\c\nd"`, dotQuote("a \"b\"\\c\nd"))
+}
@@ -0,0 +1,194 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"image"
+	"regexp"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	"github.com/timdrysdale/unipdf/v3/extractor"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+// The QR code carries only the short code, which is enough to
+// recover the full page data from an index after print-and-scan
+
+const (
+	BottomRight = iota
+	BottomLeft  = iota
+	TopRight    = iota
+	TopLeft     = iota
+)
+
+const (
+	QRPrefix    = "gradex-page:"
+	qrSize      = 56  // points, about 20mm, survives a 150dpi scan
+	qrMargin    = 12  // points from the edge of the page
+	qrPixels    = 256 // rendered image size
+	qrQuietZone = 4   // modules of white around the code
+)
+
+var ErrNoQRCode = errors.New("no page QR code found")
+
+var shortCodeRegexp = regexp.MustCompile(`[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]`)
+
+// QRPayload is the text encoded in the page's QR code
+func QRPayload(pd *PageData) string {
+	return QRPrefix + ShortCode(pd.Page.UUID)
+}
+
+// WritePageQR stamps a QR code of the page's short code in
+// the chosen corner of the current page
+func WritePageQR(c *creator.Creator, pd *PageData, corner int) error {
+
+	if pd.Page.UUID == "" {
+		return ErrNoPageUUID
+	}
+
+	img, err := QRImage(QRPayload(pd))
+	if err != nil {
+		return err
+	}
+
+	qr, err := c.NewImageFromGoImage(img)
+	if err != nil {
+		return err
+	}
+
+	qr.ScaleToWidth(qrSize)
+
+	x := float64(qrMargin)
+	y := float64(qrMargin)
+
+	switch corner {
+	case BottomRight:
+		x = c.Width() - qrMargin - qrSize
+		y = c.Height() - qrMargin - qrSize
+	case BottomLeft:
+		y = c.Height() - qrMargin - qrSize
+	case TopRight:
+		x = c.Width() - qrMargin - qrSize
+	case TopLeft:
+	default:
+		return errors.New("unknown corner")
+	}
+
+	qr.SetPos(x, y)
+
+	return c.Draw(qr)
+}
+
+// QRImage renders the payload as a QR code with a quiet zone
+func QRImage(payload string) (image.Image, error) {
+
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_MARGIN:           qrQuietZone,
+		gozxing.EncodeHintType_ERROR_CORRECTION: "M",
+	}
+
+	matrix, err := qrcode.NewQRCodeWriter().Encode(payload, gozxing.BarcodeFormat_QR_CODE, qrPixels, qrPixels, hints)
+	if err != nil {
+		return nil, err
+	}
+
+	return matrix, nil
+}
+
+// DecodeQRImage finds a page QR code in an image, such as a scanned
+// page, and returns the short code it carries
+func DecodeQRImage(img image.Image) (string, error) {
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", err
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, hints)
+	if err != nil {
+		return "", err
+	}
+
+	text := result.GetText()
+
+	if !strings.HasPrefix(text, QRPrefix) {
+		return "", ErrNoQRCode
+	}
+
+	return strings.TrimPrefix(text, QRPrefix), nil
+}
+
+// DecodePageQR returns the short codes from any page QR codes
+// found in the images on the page
+func DecodePageQR(page *pdf.PdfPage) ([]string, error) {
+
+	ex, err := extractor.New(page)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := ex.ExtractPageImages(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+
+	for _, mark := range images.Images {
+
+		img, err := mark.Image.ToGoImage()
+		if err != nil {
+			continue
+		}
+
+		code, err := DecodeQRImage(img)
+		if err != nil {
+			continue
+		}
+
+		codes = append(codes, code)
+	}
+
+	if len(codes) == 0 {
+		return codes, ErrNoQRCode
+	}
+
+	return codes, nil
+}
+
+// RecoverPageData looks up page data in the index using the QR code,
+// or failing that the short code in the visible footer text, for pages
+// that have lost their hidden page data
+func RecoverPageData(page *pdf.PdfPage, pageText string, index *PageIndex) []PageData {
+
+	var pds []PageData
+
+	codes, _ := DecodePageQR(page)
+
+	codes = append(codes, shortCodeRegexp.FindAllString(pageText, -1)...)
+
+	seen := make(map[string]bool)
+
+	for _, code := range codes {
+
+		pd, err := index.Lookup(code)
+		if err != nil {
+			continue
+		}
+
+		if seen[pd.Page.UUID] {
+			continue
+		}
+		seen[pd.Page.UUID] = true
+
+		pds = append(pds, pd)
+	}
+
+	return pds
+}
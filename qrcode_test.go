@@ -0,0 +1,171 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	"github.com/timdrysdale/unipdf/v3/extractor"
+)
+
+func TestQRRoundTrip(t *testing.T) {
+
+	pd := PageData{Page: PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c"}}
+
+	img, err := QRImage(QRPayload(&pd))
+	assert.NoError(t, err)
+
+	code, err := DecodeQRImage(img)
+	assert.NoError(t, err)
+	assert.Equal(t, ShortCode(pd.Page.UUID), code)
+
+	scan := rasterise(img, 850, 1100, 450, 700, 1.37)
+
+	code, err = DecodeQRImage(scan)
+	assert.NoError(t, err)
+	assert.Equal(t, ShortCode(pd.Page.UUID), code)
+
+	// a blank scan has no code
+	_, err = DecodeQRImage(rasterise(image.NewGray(image.Rect(0, 0, 10, 10)), 850, 1100, 0, 0, 1))
+	assert.Error(t, err)
+}
+
+func TestWritePageQR(t *testing.T) {
+
+	pd := PageData{Page: PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c"}}
+
+	for _, corner := range []int{BottomRight, BottomLeft, TopRight, TopLeft} {
+
+		c := creator.New()
+		c.SetPageMargins(0, 0, 0, 0)
+		c.SetPageSize(creator.PageSizeA4)
+		c.NewPage()
+
+		assert.NoError(t, WritePageQR(c, &pd, corner))
+
+		var buf bytes.Buffer
+		assert.NoError(t, c.Write(&buf))
+
+		page, err := readerForTest(t, buf.Bytes()).GetPage(1)
+		assert.NoError(t, err)
+
+		codes, err := DecodePageQR(page)
+		assert.NoError(t, err, "corner %d", corner)
+		assert.Equal(t, []string{ShortCode(pd.Page.UUID)}, codes, "corner %d", corner)
+
+		ex, err := extractor.New(page)
+		assert.NoError(t, err)
+
+		images, err := ex.ExtractPageImages(nil)
+		assert.NoError(t, err)
+		if !assert.Equal(t, 1, len(images.Images)) {
+			continue
+		}
+
+		// where the code should be, in pdf coordinates (y up from the bottom)
+		x, y := float64(qrMargin), float64(qrMargin)
+		if corner == BottomRight || corner == TopRight {
+			x = c.Width() - qrMargin - qrSize
+		}
+		if corner == TopRight || corner == TopLeft {
+			y = c.Height() - qrMargin - qrSize
+		}
+
+		mark := images.Images[0]
+		assert.InDelta(t, x, mark.X, 1, "corner %d", corner)
+		assert.InDelta(t, y, mark.Y, 1, "corner %d", corner)
+	}
+
+	c := creator.New()
+	c.NewPage()
+	assert.Error(t, WritePageQR(c, &pd, 4))
+	assert.Equal(t, ErrNoPageUUID, WritePageQR(c, &PageData{}, TopLeft))
+}
+
+func TestGetPageDataFromScan(t *testing.T) {
+
+	pd := PageData{
+		Exam: ExamDetails{CourseCode: "ENGI12123", UUID: "69197384-fd15-42ac-ac16-82dbe4d52dd0"},
+		Page: PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c", Number: 1, Of: 1},
+	}
+
+	index := NewPageIndex()
+	_, err := index.Add(pd)
+	assert.NoError(t, err)
+
+	qr, err := QRImage(QRPayload(&pd))
+	assert.NoError(t, err)
+
+	// a scanned page is just one big image, with no hidden text
+	scan := rasterise(qr, 827, 1169, 650, 990, 0.6)
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	img, err := c.NewImageFromGoImage(scan)
+	assert.NoError(t, err)
+	img.ScaleToWidth(c.Width())
+	img.SetPos(0, 0)
+	assert.NoError(t, c.Draw(img))
+
+	dir, err := os.MkdirTemp("", "pdfpagedata")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	inputPath := filepath.Join(dir, "scan.pdf")
+	assert.NoError(t, c.WriteToFile(inputPath))
+
+	pdm, err := GetPageDataFromFile(inputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(pdm[0]))
+
+	pdm, err = GetPageDataFromFileWithOptions(inputPath, ReadOptions{Index: index})
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(pdm[0])) {
+		assert.Equal(t, pd.Exam.CourseCode, pdm[0][0].Exam.CourseCode)
+		assert.Equal(t, pd.Page.UUID, pdm[0][0].Page.UUID)
+	}
+}
+
+// rasterise fakes a scan: the image is resampled by scale (nearest
+// neighbour), placed at x,y on an off-white page and given some noise
+func rasterise(src image.Image, width, height, x, y int, scale float64) image.Image {
+
+	dst := image.NewGray(image.Rect(0, 0, width, height))
+
+	r := rand.New(rand.NewSource(1))
+
+	for j := 0; j < height; j++ {
+		for i := 0; i < width; i++ {
+			dst.SetGray(i, j, color.Gray{Y: uint8(235 + r.Intn(20))})
+		}
+	}
+
+	b := src.Bounds()
+	w := int(float64(b.Dx()) * scale)
+	h := int(float64(b.Dy()) * scale)
+
+	for j := 0; j < h && y+j < height; j++ {
+		for i := 0; i < w && x+i < width; i++ {
+			sx := b.Min.X + int(float64(i)/scale)
+			sy := b.Min.Y + int(float64(j)/scale)
+			g := color.GrayModel.Convert(src.At(sx, sy)).(color.Gray)
+			if g.Y < 128 {
+				g.Y = uint8(20 + r.Intn(40))
+			} else {
+				g.Y = uint8(215 + r.Intn(40))
+			}
+			dst.SetGray(x+i, y+j, g)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,195 @@
+package pdfpagedata
+
+import (
+	"strings"
+
+	"github.com/timdrysdale/unipdf/v3/contentstream"
+	"github.com/timdrysdale/unipdf/v3/core"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+// ReplacePageData writes pd to the page and removes any older revisions
+// of the same page (matched on Exam.UUID and Page.UUID) in the same pass.
+// The page is added to the creator, so the creator's current page is the
+// one that has been updated. Returns the number of tokens removed.
+func ReplacePageData(c *creator.Creator, page *pdf.PdfPage, pd *PageData) (int, error) {
+
+	removed, err := RemovePageData(page, func(old PageData) bool {
+		return old.Exam.UUID == pd.Exam.UUID &&
+			old.Page.UUID == pd.Page.UUID &&
+			old.Revision <= pd.Revision
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	err = c.AddPage(page)
+	if err != nil {
+		return removed, err
+	}
+
+	return removed, MarshalPageData(c, pd)
+}
+
+// RevisionIs selects page data with exactly this revision
+func RevisionIs(revision int) func(PageData) bool {
+	return func(pd PageData) bool {
+		return pd.Revision == revision
+	}
+}
+
+// RevisionBelow selects page data older than this revision
+func RevisionBelow(revision int) func(PageData) bool {
+	return func(pd PageData) bool {
+		return pd.Revision < revision
+	}
+}
+
+// ExamUUIDIs selects page data belonging to this exam
+func ExamUUIDIs(uuid string) func(PageData) bool {
+	return func(pd PageData) bool {
+		return pd.Exam.UUID == uuid
+	}
+}
+
+// RemoveAllPageData strips every page data token from the page, including
+// any that do not unmarshal into a PageData
+func RemoveAllPageData(page *pdf.PdfPage) (int, error) {
+	return RemovePageTokens(page, func(token string) bool {
+		return true
+	})
+}
+
+// RemovePageData strips the page data records for which remove returns
+// true. Tokens that can't be unmarshalled are left alone.
+func RemovePageData(page *pdf.PdfPage, remove func(PageData) bool) (int, error) {
+	return RemovePageTokens(page, func(token string) bool {
+		var pd PageData
+		if err := DecodeToken(token, &pd); err != nil {
+			return false
+		}
+		return remove(pd)
+	})
+}
+
+// RemovePageTokens rewrites the page's content streams without the text
+// objects that hold tokens selected by remove. Each token is written as
+// its own paragraph, so the whole BT..ET block is dropped; a block is kept
+// if any token in it is not selected, or if it shows any other text, so
+// that visible text sharing a block with a token is never lost. Returns
+// the number of tokens removed.
+func RemovePageTokens(page *pdf.PdfPage, remove func(token string) bool) (int, error) {
+
+	contents, err := page.GetAllContentStreams()
+	if err != nil {
+		return 0, err
+	}
+
+	ops, err := contentstream.NewContentStreamParser(contents).Parse()
+	if err != nil {
+		return 0, err
+	}
+
+	var kept contentstream.ContentStreamOperations
+	var block contentstream.ContentStreamOperations
+	var text strings.Builder
+	inBlock := false
+	removed := 0
+
+	for _, op := range *ops {
+
+		switch op.Operand {
+		case "BT":
+			inBlock = true
+			block = contentstream.ContentStreamOperations{op}
+			text.Reset()
+			continue
+		case "ET":
+			if !inBlock {
+				break
+			}
+			inBlock = false
+			block = append(block, op)
+			if n := countRemovable(text.String(), remove); n > 0 {
+				removed += n
+				continue
+			}
+			kept = append(kept, block...)
+			continue
+		}
+
+		if inBlock {
+			block = append(block, op)
+			text.WriteString(operationText(op))
+			continue
+		}
+
+		kept = append(kept, op)
+	}
+
+	// unterminated text object, keep as found
+	if inBlock {
+		kept = append(kept, block...)
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	err = page.SetContentStreams([]string{string(kept.Bytes())}, core.NewFlateEncoder())
+
+	return removed, err
+}
+
+// countRemovable returns the number of tokens in the text, if they are
+// all to be removed and there is nothing else in it, else zero
+func countRemovable(text string, remove func(token string) bool) int {
+
+	tokens := extractTokens(text, DefaultNamespace)
+	rest := text
+
+	for _, token := range tokens {
+		if !remove(UnescapeToken(token)) {
+			return 0
+		}
+		rest = strings.Replace(rest, StartTag+token+EndTag, "", 1)
+	}
+
+	if strings.TrimSpace(rest) != "" {
+		return 0
+	}
+
+	return len(tokens)
+}
+
+// operationText returns the string shown by a text showing operator
+func operationText(op *contentstream.ContentStreamOperation) string {
+
+	switch op.Operand {
+	case "Tj", "'", "\"":
+		if len(op.Params) < 1 {
+			return ""
+		}
+		if str, ok := core.GetString(op.Params[len(op.Params)-1]); ok {
+			return str.Str()
+		}
+	case "TJ":
+		if len(op.Params) < 1 {
+			return ""
+		}
+		arr, ok := core.GetArray(op.Params[0])
+		if !ok {
+			return ""
+		}
+		var text strings.Builder
+		for _, obj := range arr.Elements() {
+			if str, ok := core.GetString(obj); ok {
+				text.WriteString(str.Str())
+			}
+		}
+		return text.String()
+	}
+
+	return ""
+}
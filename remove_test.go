@@ -0,0 +1,153 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mattetti/filebuffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+	pdf "github.com/timdrysdale/unipdf/v3/model"
+)
+
+func TestRemovePageData(t *testing.T) {
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	p := c.NewParagraph("Visible text that must survive")
+	p.SetFontSize(12)
+	p.SetPos(10, 10)
+	c.Draw(p)
+
+	for rev := 0; rev < 3; rev++ {
+		pd := PageData{
+			Exam:     ExamDetails{UUID: "exam-a"},
+			Page:     PageDetails{UUID: "page-a"},
+			Revision: rev,
+		}
+		assert.NoError(t, MarshalPageData(c, &pd))
+	}
+	pd := PageData{Exam: ExamDetails{UUID: "exam-b"}}
+	assert.NoError(t, MarshalPageData(c, &pd))
+
+	page := firstPageRoundTrip(t, c)
+
+	removed, err := RemovePageData(page, RevisionBelow(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	removed, err = RemovePageData(page, ExamUUIDIs("exam-b"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	removed, err = RemovePageData(page, ExamUUIDIs("exam-b"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	c2 := creator.New()
+	assert.NoError(t, c2.AddPage(page))
+	page = firstPageRoundTrip(t, c2)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(pds)) {
+		assert.Equal(t, 2, pds[0].Revision)
+	}
+
+	text, err := ReadPageString(page)
+	assert.NoError(t, err)
+	assert.Contains(t, text, "Visible text that must survive")
+
+	removed, err = RemoveAllPageData(page)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestReplacePageData(t *testing.T) {
+
+	c := creator.New()
+	c.SetPageMargins(0, 0, 0, 0)
+	c.SetPageSize(creator.PageSizeA4)
+	c.NewPage()
+
+	old := PageData{
+		Exam:     ExamDetails{UUID: "exam-a"},
+		Page:     PageDetails{UUID: "page-a"},
+		Revision: 0,
+	}
+	assert.NoError(t, MarshalPageData(c, &old))
+
+	other := PageData{
+		Exam: ExamDetails{UUID: "exam-a"},
+		Page: PageDetails{UUID: "page-b"},
+	}
+	assert.NoError(t, MarshalPageData(c, &other))
+
+	page := firstPageRoundTrip(t, c)
+
+	next := old
+	next.Revision = 1
+	next.ToDo = "mark"
+
+	c2 := creator.New()
+	removed, err := ReplacePageData(c2, page, &next)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	page = firstPageRoundTrip(t, c2)
+
+	pds, err := UnmarshalPageData(page)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pds))
+	for _, pd := range pds {
+		if pd.Page.UUID == "page-a" {
+			assert.Equal(t, 1, pd.Revision)
+			assert.Equal(t, "mark", pd.ToDo)
+		}
+	}
+}
+
+// firstPageRoundTrip writes the creator to memory and reads back page 1
+func firstPageRoundTrip(t *testing.T, c *creator.Creator) *pdf.PdfPage {
+
+	var buf bytes.Buffer
+
+	err := c.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bufslice []byte
+	fbuf := filebuffer.New(bufslice)
+	fbuf.Write(buf.Bytes())
+
+	pdfReader, err := pdf.NewPdfReader(fbuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := pdfReader.GetPage(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return page
+}
+
+func TestCountRemovable(t *testing.T) {
+
+	all := func(token string) bool { return true }
+	none := func(token string) bool { return false }
+
+	assert.Equal(t, 1, countRemovable(StartTag+"{}"+EndTag, all))
+	assert.Equal(t, 2, countRemovable(StartTag+"{}"+EndTag+" "+StartTag+"{}"+EndTag, all))
+	assert.Equal(t, 0, countRemovable(StartTag+"{}"+EndTag, none))
+	assert.Equal(t, 0, countRemovable("no tokens here", all))
+
+	// visible text in the same block must not be dropped with the token
+	assert.Equal(t, 0, countRemovable("Name: "+StartTag+"{}"+EndTag, all))
+	assert.Equal(t, 0, countRemovable(StartTag+"{}"+EndTag+"<other>x</other>", all))
+}
@@ -0,0 +1,269 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A question can carry a rubric, so markers know what each mark is for.
+// Each criterion is either met, for its Marks, or is marked in bands,
+// e.g.
+//
+//	rubric:
+//	  - {id: method, descriptor: "correct method", marks: 2}
+//	  - id: answer
+//	    bands:
+//	      - {id: weak, descriptor: "partly right", min: 0, max: 1}
+//	      - {id: strong, descriptor: "right, and explained", min: 2, max: 3}
+//
+// A marking action records which criteria it awarded, with the band and
+// the mark within it for those that are banded (the mark can be left out
+// of a band with only one mark in it). The mark given should be the sum.
+
+var (
+	ErrUnknownCriterion = errors.New("not in the rubric")
+	ErrCriterionTwice   = errors.New("awarded more than once")
+	ErrNoBand           = errors.New("banded, but no band given")
+	ErrUnknownBand      = errors.New("no such band")
+	ErrNotBanded        = errors.New("not banded, but a band was given")
+	ErrNoBandMark       = errors.New("no mark given within the band")
+	ErrOutsideBand      = errors.New("mark is outside the band")
+	ErrWrongMark        = errors.New("mark is not the criterion's marks")
+)
+
+// RubricError says which criterion awarded could not be marked
+type RubricError struct {
+	Criterion string
+	Err       error
+}
+
+func (e *RubricError) Error() string {
+	return fmt.Sprintf("criterion %s: %v", e.Criterion, e.Err)
+}
+
+func (e *RubricError) Unwrap() error {
+	return e.Err
+}
+
+// Criterion finds a criterion in the question's rubric
+func (q QuestionDetails) Criterion(id string) (CriterionDetails, bool) {
+
+	for _, c := range q.Rubric {
+		if c.ID == id {
+			return c, true
+		}
+	}
+
+	return CriterionDetails{}, false
+}
+
+// Band finds one of the criterion's bands
+func (c CriterionDetails) Band(id string) (BandDetails, bool) {
+
+	for _, b := range c.Bands {
+		if b.ID == id {
+			return b, true
+		}
+	}
+
+	return BandDetails{}, false
+}
+
+// Available is the most the criterion can give
+func (c CriterionDetails) Available() Mark {
+
+	if len(c.Bands) == 0 {
+		return c.Marks
+	}
+
+	var max Mark
+	for _, b := range c.Bands {
+		if b.Max.Cmp(max) > 0 {
+			max = b.Max
+		}
+	}
+
+	return max
+}
+
+// RubricAvailable is the most the rubric can give
+func RubricAvailable(rubric []CriterionDetails) Mark {
+
+	var total Mark
+	for _, c := range rubric {
+		total = total.Add(c.Available())
+	}
+
+	return total
+}
+
+// RubricMark works out the mark from the criteria awarded, returning a
+// *RubricError for the first that doesn't fit the rubric
+func RubricMark(rubric []CriterionDetails, awards []CriterionAward) (Mark, error) {
+
+	q := QuestionDetails{Rubric: rubric}
+	awarded := make(map[string]bool)
+
+	var total Mark
+
+	for _, a := range awards {
+
+		m, err := criterionMark(q, a)
+		if err == nil && awarded[a.Criterion] {
+			err = ErrCriterionTwice
+		}
+		if err != nil {
+			return Mark{}, &RubricError{Criterion: a.Criterion, Err: err}
+		}
+
+		awarded[a.Criterion] = true
+		total = total.Add(m)
+	}
+
+	return total, nil
+}
+
+func criterionMark(q QuestionDetails, a CriterionAward) (Mark, error) {
+
+	c, ok := q.Criterion(a.Criterion)
+	if !ok {
+		return Mark{}, ErrUnknownCriterion
+	}
+
+	if len(c.Bands) == 0 {
+		if a.Band != "" {
+			return Mark{}, ErrNotBanded
+		}
+		if a.Mark != nil && *a.Mark != c.Marks {
+			return Mark{}, ErrWrongMark
+		}
+		return c.Marks, nil
+	}
+
+	if a.Band == "" {
+		return Mark{}, ErrNoBand
+	}
+
+	b, ok := c.Band(a.Band)
+	if !ok {
+		return Mark{}, ErrUnknownBand
+	}
+
+	if a.Mark == nil {
+		if b.Min != b.Max {
+			return Mark{}, ErrNoBandMark
+		}
+		return b.Max, nil
+	}
+
+	if a.Mark.Cmp(b.Min) < 0 || a.Mark.Cmp(b.Max) > 0 {
+		return Mark{}, ErrOutsideBand
+	}
+
+	return *a.Mark, nil
+}
+
+// CheckRubric checks the rubric of a question and its parts, and that the
+// mark given by each action that awarded criteria is what they add up to.
+// Paths are relative to the question. Validate does the same for every
+// question in a record.
+func CheckRubric(q QuestionDetails) []Problem {
+
+	v := &validator{}
+
+	var check func(path string, q QuestionDetails)
+	check = func(path string, q QuestionDetails) {
+		v.rubric(path, q)
+		for i, part := range q.Parts {
+			check(fmt.Sprintf("%s/parts/%d", path, i), part)
+		}
+	}
+
+	check("", q)
+
+	return v.problems
+}
+
+func (v *validator) rubric(path string, q QuestionDetails) {
+
+	ids := make(map[string]bool)
+
+	for i, c := range q.Rubric {
+
+		p := fmt.Sprintf("%s/rubric/%d", path, i)
+
+		if ids[c.ID] {
+			v.add(SeverityError, p+"/id", "criterion %s is in the rubric twice", c.ID)
+		}
+		ids[c.ID] = true
+
+		bands := make(map[string]bool)
+
+		for j, b := range c.Bands {
+
+			bp := fmt.Sprintf("%s/bands/%d", p, j)
+
+			if bands[b.ID] {
+				v.add(SeverityError, bp+"/id", "band %s is in criterion %s twice", b.ID, c.ID)
+			}
+			bands[b.ID] = true
+
+			if b.Min.Cmp(b.Max) > 0 {
+				v.add(SeverityError, bp, "band %s goes from %v down to %v", b.ID, b.Min, b.Max)
+			}
+		}
+	}
+
+	if len(q.Rubric) > 0 && !q.MarksAvailable.IsZero() {
+		if available := RubricAvailable(q.Rubric); available != q.MarksAvailable {
+			v.add(SeverityWarning, path+"/rubric",
+				"rubric gives up to %v, but %v available", available, q.MarksAvailable)
+		}
+	}
+
+	v.awards(path+"/markers", q, q.Marking)
+	v.awards(path+"/moderators", q, q.Moderating)
+	v.awards(path+"/checkers", q, q.Checking)
+}
+
+// awards checks the criteria awarded by each action against the mark given
+func (v *validator) awards(path string, q QuestionDetails, actions []MarkingAction) {
+
+	for i, a := range actions {
+
+		if len(a.Criteria) == 0 {
+			continue
+		}
+
+		p := fmt.Sprintf("%s/%d", path, i)
+
+		if len(q.Rubric) == 0 {
+			v.add(SeverityError, p+"/criteria", "criteria awarded, but the question has no rubric")
+			continue
+		}
+
+		awarded := make(map[string]bool)
+		var total Mark
+		ok := true
+
+		for j, award := range a.Criteria {
+
+			m, err := criterionMark(q, award)
+			if err == nil && awarded[award.Criterion] {
+				err = ErrCriterionTwice
+			}
+			if err != nil {
+				v.add(SeverityError, fmt.Sprintf("%s/criteria/%d", p, j), "criterion %s: %v", award.Criterion, err)
+				ok = false
+				continue
+			}
+
+			awarded[award.Criterion] = true
+			total = total.Add(m)
+		}
+
+		if ok && total != a.Mark.Given {
+			v.add(SeverityError, p+"/mark/given", "criteria awarded add up to %v, not %v", total, a.Mark.Given)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func markPtr(f float64) *Mark {
+	m := NewMark(f)
+	return &m
+}
+
+func rubricQuestion() QuestionDetails {
+	return QuestionDetails{
+		Name:           "Q1",
+		MarksAvailable: NewMark(6),
+		Rubric: []CriterionDetails{
+			CriterionDetails{ID: "method", Descriptor: "correct method", Marks: NewMark(2)},
+			CriterionDetails{ID: "answer", Bands: []BandDetails{
+				BandDetails{ID: "weak", Descriptor: "partly right", Min: NewMark(0), Max: NewMark(1.5)},
+				BandDetails{ID: "strong", Descriptor: "right, and explained", Min: NewMark(2), Max: NewMark(3)},
+			}},
+			CriterionDetails{ID: "units", Bands: []BandDetails{
+				BandDetails{ID: "given", Min: NewMark(1), Max: NewMark(1)},
+			}},
+		},
+	}
+}
+
+func TestRubricAvailable(t *testing.T) {
+	assert.Equal(t, NewMark(6), RubricAvailable(rubricQuestion().Rubric))
+}
+
+func TestRubricMark(t *testing.T) {
+
+	rubric := rubricQuestion().Rubric
+
+	m, err := RubricMark(rubric, []CriterionAward{
+		CriterionAward{Criterion: "method"},
+		CriterionAward{Criterion: "answer", Band: "weak", Mark: markPtr(1.5)},
+		CriterionAward{Criterion: "units", Band: "given"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, NewMark(4.5), m)
+
+	m, err = RubricMark(rubric, nil)
+	assert.NoError(t, err)
+	assert.True(t, m.IsZero())
+
+	bad := map[error]CriterionAward{
+		ErrUnknownCriterion: CriterionAward{Criterion: "style"},
+		ErrNotBanded:        CriterionAward{Criterion: "method", Band: "weak"},
+		ErrNoBand:           CriterionAward{Criterion: "answer", Mark: markPtr(1)},
+		ErrUnknownBand:      CriterionAward{Criterion: "answer", Band: "perfect", Mark: markPtr(3)},
+		ErrNoBandMark:       CriterionAward{Criterion: "answer", Band: "strong"},
+		ErrOutsideBand:      CriterionAward{Criterion: "answer", Band: "strong", Mark: markPtr(1)},
+		ErrWrongMark:        CriterionAward{Criterion: "method", Mark: markPtr(1)},
+	}
+
+	for want, award := range bad {
+		_, err := RubricMark(rubric, []CriterionAward{award})
+		assert.Equal(t, &RubricError{Criterion: award.Criterion, Err: want}, err)
+	}
+
+	_, err = RubricMark(rubric, []CriterionAward{
+		CriterionAward{Criterion: "method"},
+		CriterionAward{Criterion: "method"},
+	})
+	assert.Equal(t, ErrCriterionTwice, err.(*RubricError).Unwrap())
+}
+
+func TestCheckRubric(t *testing.T) {
+
+	q := rubricQuestion()
+
+	q.Marking = []MarkingAction{
+		MarkingAction{
+			Mark: MarkDetails{Given: NewMark(5)},
+			Criteria: []CriterionAward{
+				CriterionAward{Criterion: "method"},
+				CriterionAward{Criterion: "answer", Band: "strong", Mark: markPtr(3)},
+			},
+		},
+		// no criteria, so not checked
+		MarkingAction{Mark: MarkDetails{Given: NewMark(1)}},
+	}
+
+	assert.Empty(t, CheckRubric(q))
+
+	q.Marking[0].Mark.Given = NewMark(4)
+	q.Moderating = []MarkingAction{
+		MarkingAction{Criteria: []CriterionAward{CriterionAward{Criterion: "style"}}},
+	}
+	q.Rubric[1].Bands[1].Min = NewMark(4)
+	q.Parts = []QuestionDetails{
+		QuestionDetails{Checking: []MarkingAction{
+			MarkingAction{Criteria: []CriterionAward{CriterionAward{Criterion: "method"}}},
+		}},
+	}
+
+	var got []string
+	for _, p := range CheckRubric(q) {
+		got = append(got, p.Severity.String()+" "+p.Path)
+	}
+
+	assert.Equal(t, []string{
+		"error /rubric/1/bands/1",
+		"error /markers/0/criteria/1",
+		"error /moderators/0/criteria/0",
+		"error /parts/0/checkers/0/criteria",
+	}, got)
+
+	// the rubric and marks available disagree
+	q = rubricQuestion()
+	q.MarksAvailable = NewMark(10)
+	problems := CheckRubric(q)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, SeverityWarning, problems[0].Severity)
+	assert.Equal(t, "/rubric", problems[0].Path)
+}
+
+func TestValidateRubric(t *testing.T) {
+
+	q := rubricQuestion()
+	q.Marking = []MarkingAction{
+		MarkingAction{
+			Mark:     MarkDetails{Given: NewMark(3), Available: NewMark(6)},
+			Criteria: []CriterionAward{CriterionAward{Criterion: "method"}},
+		},
+	}
+
+	problems := Validate(PageData{Questions: []QuestionDetails{q}})
+
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, "/questions/0/markers/0/mark/given", problems[0].Path)
+	assert.Equal(t, "criteria awarded add up to 2, not 3", problems[0].Message)
+}
+
+func TestRubricJSON(t *testing.T) {
+
+	q := rubricQuestion()
+	q.Marking = []MarkingAction{
+		MarkingAction{Criteria: []CriterionAward{
+			CriterionAward{Criterion: "method"},
+			CriterionAward{Criterion: "answer", Band: "weak", Mark: markPtr(0.5)},
+		}},
+	}
+
+	data, err := json.Marshal(q)
+	assert.NoError(t, err)
+
+	var out QuestionDetails
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, q, out)
+
+	var generic map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &generic))
+	criteria := generic["markers"].([]interface{})[0].(map[string]interface{})["criteria"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"criterion": "method"}, criteria[0])
+
+	// questions without a rubric are written as before
+	data, err = json.Marshal(QuestionDetails{Marking: []MarkingAction{MarkingAction{}}})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "rubric")
+	assert.NotContains(t, string(data), "criteria")
+}
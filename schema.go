@@ -0,0 +1,374 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The JSON Schema for PageData is generated from the types in types.go,
+// so it can't drift from them, and checked in at schema/pagedata.schema.json
+// for other languages to use. Constraints come from each field's schema tag:
+//   required      the field must be present
+//   minimum=n     a number must be at least n
+//   minLength=n   a string must be at least n characters
+// Unknown fields are allowed, since a newer tool may have added them.
+
+const (
+	SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	SchemaID    = "https://github.com/timdrysdale/pdfpagedata/schema/pagedata.schema.json"
+)
+
+// SchemaNode is the subset of JSON Schema that the generator writes
+type SchemaNode struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Properties           map[string]*SchemaNode `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *SchemaNode            `json:"items,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Defs                 map[string]*SchemaNode `json:"$defs,omitempty"`
+}
+
+// SchemaViolation is one way in which a record breaks the schema,
+// at Path, a JSON pointer (RFC 6901)
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+// SchemaError lists every violation found in a record
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+
+	var msgs []string
+
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Path+": "+v.Message)
+	}
+
+	return fmt.Sprintf("page data does not match schema: %s", strings.Join(msgs, "; "))
+}
+
+// PageDataSchema generates the schema from the Go types
+func PageDataSchema() *SchemaNode {
+
+	defs := make(map[string]*SchemaNode)
+
+	root := schemaForStruct(reflect.TypeOf(PageData{}), defs)
+	delete(defs, "PageData")
+
+	root.Schema = SchemaDraft
+	root.ID = SchemaID
+	root.Title = "PageData"
+	root.Defs = defs
+
+	return root
+}
+
+// Schema is the generated schema as indented JSON, as checked in
+func Schema() ([]byte, error) {
+
+	data, err := json.MarshalIndent(PageDataSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+func schemaForStruct(t reflect.Type, defs map[string]*SchemaNode) *SchemaNode {
+
+	allow := true
+
+	node := &SchemaNode{
+		Type:                 "object",
+		Properties:           make(map[string]*SchemaNode),
+		AdditionalProperties: &allow,
+	}
+
+	// placeholder stops recursive types (Parts) looping forever
+	defs[t.Name()] = node
+
+	for i := 0; i < t.NumField(); i++ {
+
+		field := t.Field(i)
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		prop := schemaForType(field.Type, defs)
+
+		for _, opt := range strings.Split(field.Tag.Get("schema"), ",") {
+
+			key, value := opt, ""
+			if j := strings.IndexByte(opt, '='); j >= 0 {
+				key, value = opt[:j], opt[j+1:]
+			}
+
+			switch key {
+			case "required":
+				node.Required = append(node.Required, name)
+			case "minimum":
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					prop.Minimum = &f
+				}
+			case "minLength":
+				if n, err := strconv.Atoi(value); err == nil {
+					prop.MinLength = &n
+				}
+			}
+		}
+
+		node.Properties[name] = prop
+	}
+
+	return node
+}
+
+func schemaForType(t reflect.Type, defs map[string]*SchemaNode) *SchemaNode {
+
+	// a Mark is a struct, but written as a number
+	if t == reflect.TypeOf(Mark{}) {
+		return &SchemaNode{Type: "number"}
+	}
+
+	switch t.Kind() {
+
+	case reflect.String:
+		return &SchemaNode{Type: "string"}
+
+	case reflect.Bool:
+		return &SchemaNode{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &SchemaNode{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &SchemaNode{Type: "number"}
+
+	case reflect.Slice:
+		// a nil slice is written as null
+		return &SchemaNode{
+			Type:  []string{"array", "null"},
+			Items: schemaForType(t.Elem(), defs),
+		}
+
+	case reflect.Ptr:
+		return schemaForType(t.Elem(), defs)
+
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			schemaForStruct(t, defs)
+		}
+		return &SchemaNode{Ref: "#/$defs/" + t.Name()}
+	}
+
+	return &SchemaNode{}
+}
+
+// ValidateSchema checks a decoded record against the schema, returning
+// a *SchemaError listing every violation
+func ValidateSchema(pd PageData) error {
+
+	data, err := json.Marshal(pd)
+	if err != nil {
+		return err
+	}
+
+	return ValidateSchemaJSON(data)
+}
+
+// ValidateSchemaJSON checks a record, as JSON, against the schema. As well
+// as the schema, the page number must not be more than the number of pages,
+// which JSON Schema can't express.
+func ValidateSchemaJSON(data []byte) error {
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	root := PageDataSchema()
+
+	var violations []SchemaViolation
+
+	validateNode(root, root, v, "", &violations)
+
+	if page, ok := getObject(v, "page"); ok {
+		number, okNumber := page["number"].(json.Number)
+		of, okOf := page["of"].(json.Number)
+		if okNumber && okOf {
+			n, errN := number.Float64()
+			o, errO := of.Float64()
+			if errN == nil && errO == nil && n > o {
+				violations = append(violations, SchemaViolation{
+					Path:    "/page/number",
+					Message: fmt.Sprintf("page %s is after the last page (%s)", number, of),
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		sort.SliceStable(violations, func(i, j int) bool {
+			return violations[i].Path < violations[j].Path
+		})
+		return &SchemaError{Violations: violations}
+	}
+
+	return nil
+}
+
+func getObject(v interface{}, key string) (map[string]interface{}, bool) {
+
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	child, ok := obj[key].(map[string]interface{})
+
+	return child, ok
+}
+
+func validateNode(root, node *SchemaNode, v interface{}, path string, violations *[]SchemaViolation) {
+
+	if node.Ref != "" {
+		node = root.Defs[strings.TrimPrefix(node.Ref, "#/$defs/")]
+		if node == nil {
+			return
+		}
+	}
+
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, SchemaViolation{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if node.Type != nil && !matchesType(node.Type, v) {
+		report("expected %s, got %s", typeName(node.Type), jsonTypeOf(v))
+		return
+	}
+
+	switch t := v.(type) {
+
+	case map[string]interface{}:
+		for _, name := range node.Required {
+			if _, ok := t[name]; !ok {
+				*violations = append(*violations, SchemaViolation{
+					Path:    path + "/" + escapePointer(name),
+					Message: "required",
+				})
+			}
+		}
+		for name, prop := range node.Properties {
+			if child, ok := t[name]; ok {
+				validateNode(root, prop, child, path+"/"+escapePointer(name), violations)
+			}
+		}
+
+	case []interface{}:
+		if node.Items != nil {
+			for i, child := range t {
+				validateNode(root, node.Items, child, fmt.Sprintf("%s/%d", path, i), violations)
+			}
+		}
+
+	case json.Number:
+		if node.Minimum != nil {
+			if f, err := t.Float64(); err == nil && f < *node.Minimum {
+				report("%s is less than the minimum of %v", t, *node.Minimum)
+			}
+		}
+
+	case string:
+		if node.MinLength != nil && len([]rune(t)) < *node.MinLength {
+			if *node.MinLength == 1 {
+				report("must not be empty")
+			} else {
+				report("shorter than %d characters", *node.MinLength)
+			}
+		}
+	}
+}
+
+func matchesType(want interface{}, v interface{}) bool {
+
+	switch w := want.(type) {
+	case string:
+		return matchesTypeName(w, v)
+	case []string:
+		for _, name := range w {
+			if matchesTypeName(name, v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchesTypeName(name string, v interface{}) bool {
+
+	got := jsonTypeOf(v)
+
+	if name == "number" && got == "integer" {
+		return true
+	}
+
+	return name == got
+}
+
+func jsonTypeOf(v interface{}) string {
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case json.Number:
+		if f, err := t.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+
+	return "unknown"
+}
+
+func typeName(want interface{}) string {
+
+	if names, ok := want.([]string); ok {
+		return strings.Join(names, " or ")
+	}
+
+	return fmt.Sprint(want)
+}
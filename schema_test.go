@@ -0,0 +1,103 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateSchema = flag.Bool("update-schema", false, "rewrite schema/pagedata.schema.json")
+
+const schemaFile = "schema/pagedata.schema.json"
+
+func TestSchemaUpToDate(t *testing.T) {
+
+	generated, err := Schema()
+	assert.NoError(t, err)
+
+	if *updateSchema {
+		assert.NoError(t, ioutil.WriteFile(schemaFile, generated, 0644))
+	}
+
+	checkedIn, err := ioutil.ReadFile(schemaFile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(checkedIn), string(generated), "run go test -run TestSchemaUpToDate -update-schema")
+}
+
+func validSchemaPageData() PageData {
+	pd := codecTestPageData()
+	pd.Questions[0].Marking[0].Mark = MarkDetails{Given: NewMark(2.25), Available: NewMark(7.5)}
+	return pd
+}
+
+func TestValidateSchema(t *testing.T) {
+
+	assert.NoError(t, ValidateSchema(validSchemaPageData()))
+
+	pd := validSchemaPageData()
+	pd.Exam.UUID = ""
+	pd.Page.Number = 21
+	pd.Questions[0].MarksAwarded = NewMark(-1)
+	pd.Questions[0].Marking[0].Mark.Given = NewMark(-0.5)
+
+	err := ValidateSchema(pd)
+	if assert.IsType(t, &SchemaError{}, err) {
+		var paths []string
+		for _, v := range err.(*SchemaError).Violations {
+			paths = append(paths, v.Path)
+		}
+		assert.Equal(t, []string{
+			"/exam/UUID",
+			"/page/number",
+			"/questions/0/markers/0/mark/given",
+			"/questions/0/marksAwarded",
+		}, paths)
+	}
+
+	pd = validSchemaPageData()
+	pd.Page.Number = 0
+	err = ValidateSchema(pd)
+	if assert.IsType(t, &SchemaError{}, err) {
+		assert.Equal(t, "/page/number", err.(*SchemaError).Violations[0].Path)
+	}
+}
+
+func TestValidateSchemaJSON(t *testing.T) {
+
+	err := ValidateSchemaJSON([]byte(`{"page":{"number":"one","of":2},"questions":{}}`))
+	if assert.IsType(t, &SchemaError{}, err) {
+		assert.Equal(t, []SchemaViolation{
+			SchemaViolation{Path: "/exam", Message: "required"},
+			SchemaViolation{Path: "/page/number", Message: "expected integer, got string"},
+			SchemaViolation{Path: "/questions", Message: "expected array or null, got object"},
+		}, err.(*SchemaError).Violations)
+	}
+
+	// unknown fields are fine
+	err = ValidateSchemaJSON([]byte(fromNewerTool))
+	if assert.IsType(t, &SchemaError{}, err) {
+		for _, v := range err.(*SchemaError).Violations {
+			assert.NotEqual(t, "/exam/term", v.Path)
+		}
+	}
+
+	assert.Error(t, ValidateSchemaJSON([]byte(`{`)))
+}
+
+func TestSchemaIsValidJSON(t *testing.T) {
+
+	data, err := Schema()
+	assert.NoError(t, err)
+
+	var s map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &s))
+	assert.Equal(t, SchemaDraft, s["$schema"])
+
+	defs := s["$defs"].(map[string]interface{})
+	for _, name := range []string{"ExamDetails", "PageDetails", "QuestionDetails", "MarkingAction", "ProcessingDetails"} {
+		assert.Contains(t, defs, name)
+	}
+}
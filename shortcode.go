@@ -0,0 +1,177 @@
+package pdfpagedata
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+)
+
+// Short codes are printed in the visible footer so that a page can be
+// identified after print-and-scan has stripped the hidden page data.
+// They are the first 40 bits of a hash of Page.UUID, in base32 (which
+// has no 0/1/8 to confuse with O/I/B), plus a Luhn mod 32 check
+// character, grouped for reading aloud e.g. ABCD-EFGH-K
+
+const shortCodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+
+var (
+	ErrBadShortCode   = errors.New("short code is malformed")
+	ErrBadCheckDigit  = errors.New("short code check digit does not match")
+	ErrShortCodeClash = errors.New("short code already used by another page")
+	ErrNoPageUUID     = errors.New("page data has no page UUID")
+	ErrPageNotFound   = errors.New("no page data for short code")
+)
+
+// ShortCode returns the printable code for a page UUID
+func ShortCode(pageUUID string) string {
+
+	sum := sha256.Sum256([]byte(pageUUID))
+
+	code := base32.StdEncoding.EncodeToString(sum[:5])
+
+	code = code + string(checkCharacter(code))
+
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:9]
+}
+
+// NormaliseShortCode strips grouping and fixes the usual transcription
+// slips, then verifies the check character. The result has no hyphens.
+func NormaliseShortCode(code string) (string, error) {
+
+	code = strings.ToUpper(code)
+
+	code = strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		case '0':
+			return 'O'
+		case '1':
+			return 'I'
+		case '8':
+			return 'B'
+		}
+		return r
+	}, code)
+
+	if len(code) != 9 {
+		return "", ErrBadShortCode
+	}
+
+	for _, r := range code {
+		if !strings.ContainsRune(shortCodeAlphabet, r) {
+			return "", ErrBadShortCode
+		}
+	}
+
+	if checkCharacter(code[0:8]) != code[8] {
+		return "", ErrBadCheckDigit
+	}
+
+	return code, nil
+}
+
+// checkCharacter implements Luhn mod N over the base32 alphabet
+func checkCharacter(code string) byte {
+
+	n := len(shortCodeAlphabet)
+	factor := 2
+	sum := 0
+
+	for i := len(code) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(shortCodeAlphabet, code[i])
+		addend = (addend / n) + (addend % n)
+		sum += addend
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+
+	return shortCodeAlphabet[(n-(sum%n))%n]
+}
+
+// PageIndex maps short codes back to the full page data, keeping
+// the highest revision seen for each page
+type PageIndex struct {
+	pages map[string]PageData
+}
+
+func NewPageIndex() *PageIndex {
+	return &PageIndex{pages: make(map[string]PageData)}
+}
+
+// Add puts the page data in the index and returns its short code
+func (idx *PageIndex) Add(pd PageData) (string, error) {
+
+	if pd.Page.UUID == "" {
+		return "", ErrNoPageUUID
+	}
+
+	code := ShortCode(pd.Page.UUID)
+
+	key, err := NormaliseShortCode(code)
+	if err != nil {
+		return "", err
+	}
+
+	if old, ok := idx.pages[key]; ok {
+		if old.Page.UUID != pd.Page.UUID {
+			return "", ErrShortCodeClash
+		}
+		if old.Revision > pd.Revision {
+			return code, nil
+		}
+	}
+
+	idx.pages[key] = pd
+
+	return code, nil
+}
+
+// AddFile indexes every page data record found in a pdf. Tokens that
+// are left out, as suspect or not decoding, are reported in a
+// *DecodeError, once the rest have been indexed.
+func (idx *PageIndex) AddFile(inputPath string) error {
+
+	pdm, err := GetPageDataFromFile(inputPath)
+	if _, partial := err.(*DecodeError); err != nil && !partial {
+		return err
+	}
+
+	for _, pds := range pdm {
+		for _, pd := range pds {
+			if pd.Page.UUID == "" {
+				continue
+			}
+			if _, err := idx.Add(pd); err != nil {
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// Lookup returns the page data for a short code, as typed or
+// scanned, with or without hyphens
+func (idx *PageIndex) Lookup(code string) (PageData, error) {
+
+	key, err := NormaliseShortCode(code)
+	if err != nil {
+		return PageData{}, err
+	}
+
+	pd, ok := idx.pages[key]
+	if !ok {
+		return PageData{}, ErrPageNotFound
+	}
+
+	return pd, nil
+}
+
+func (idx *PageIndex) Len() int {
+	return len(idx.pages)
+}
@@ -0,0 +1,78 @@
+package pdfpagedata
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortCode(t *testing.T) {
+
+	code := ShortCode("a94a71f5-b867-45f9-92f6-ddcc8c39bd9c")
+
+	assert.Equal(t, 11, len(code))
+	assert.Equal(t, code, ShortCode("a94a71f5-b867-45f9-92f6-ddcc8c39bd9c"))
+	assert.NotEqual(t, code, ShortCode("a94a71f5-b867-45f9-92f6-ddcc8c39bd9d"))
+
+	norm, err := NormaliseShortCode(code)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(code, "-", "", -1), norm)
+
+	// typed sloppily
+	_, err = NormaliseShortCode(strings.ToLower(strings.Replace(code, "-", " ", -1)))
+	assert.NoError(t, err)
+
+	// every single character slip is caught
+	for i := 0; i < len(norm); i++ {
+		for _, r := range shortCodeAlphabet {
+			if byte(r) == norm[i] {
+				continue
+			}
+			bad := norm[:i] + string(r) + norm[i+1:]
+			_, err = NormaliseShortCode(bad)
+			assert.Equal(t, ErrBadCheckDigit, err, bad)
+		}
+	}
+
+	_, err = NormaliseShortCode("ABC")
+	assert.Equal(t, ErrBadShortCode, err)
+
+	_, err = NormaliseShortCode("ABCD-EFGH-!")
+	assert.Equal(t, ErrBadShortCode, err)
+}
+
+func TestPageIndex(t *testing.T) {
+
+	idx := NewPageIndex()
+
+	_, err := idx.Add(PageData{})
+	assert.Equal(t, ErrNoPageUUID, err)
+
+	pd := PageData{
+		Exam: ExamDetails{CourseCode: "ENGI12123"},
+		Page: PageDetails{UUID: "a94a71f5-b867-45f9-92f6-ddcc8c39bd9c", Number: 3, Of: 10},
+	}
+
+	code, err := idx.Add(pd)
+	assert.NoError(t, err)
+
+	newer := pd
+	newer.Revision = 2
+	_, err = idx.Add(newer)
+	assert.NoError(t, err)
+
+	_, err = idx.Add(pd)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, idx.Len())
+
+	found, err := idx.Lookup(strings.ToLower(code))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, found.Revision)
+
+	_, err = idx.Lookup(ShortCode("some-other-page"))
+	assert.Equal(t, ErrPageNotFound, err)
+
+	assert.Equal(t, "ENGI12123   page 3 of 10   "+code, FooterText(&pd))
+}
@@ -0,0 +1,435 @@
+package pdfpagedata
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Totals add up the marks on questions, pages, scripts (all the pages
+// by one author for one exam) and exams. A question's mark is taken
+// from the latest done action of the kind asked for, or by default the
+// latest done action of any kind, so a re-mark after checking counts.
+// Failing that, it is the question's own MarksAwarded, for records from
+// before marking actions were recorded.
+//
+// A question with parts is the total of its parts, unless none of them
+// has been marked, in which case it is its own mark (e.g. the marker
+// only gave a mark for the whole question). Where both the question and
+// its parts have marks or marks available that don't agree, a warning
+// is added to the page's problems.
+
+type MarkSource int
+
+const (
+	LatestMarks     MarkSource = iota
+	MarkingMarks    MarkSource = iota
+	ModeratingMarks MarkSource = iota
+	CheckingMarks   MarkSource = iota
+	AwardedMarks    MarkSource = iota
+)
+
+func (s MarkSource) String() string {
+	switch s {
+	case LatestMarks:
+		return "latest"
+	case MarkingMarks:
+		return "marking"
+	case ModeratingMarks:
+		return "moderating"
+	case CheckingMarks:
+		return "checking"
+	case AwardedMarks:
+		return "awarded"
+	}
+	return fmt.Sprintf("source(%d)", int(s))
+}
+
+// Total is marks awarded out of marks available, with a count of the
+// questions (or parts) that were, and weren't, marked
+type Total struct {
+	Awarded   Mark
+	Available Mark
+	Marked    int
+	Unmarked  int
+}
+
+func (t *Total) add(o Total) {
+	t.Awarded = t.Awarded.Add(o.Awarded)
+	t.Available = t.Available.Add(o.Available)
+	t.Marked += o.Marked
+	t.Unmarked += o.Unmarked
+}
+
+// Complete reports whether everything has been marked
+func (t Total) Complete() bool {
+	return t.Unmarked == 0
+}
+
+type QuestionTotal struct {
+	Total
+	UUID    string
+	Name    string
+	Section string
+	Number  int
+	From    MarkSource // where the question's own mark came from, if it has one
+	Parts   []QuestionTotal
+}
+
+type PageTotal struct {
+	Total
+	PageUUID  string
+	Number    int
+	Revision  int
+	Questions []QuestionTotal
+	Problems  []Problem
+}
+
+type ScriptTotal struct {
+	Total
+	ExamUUID string
+	Author   string // Anonymous, or Identity if there isn't one
+	Rounded  Mark   // Awarded, rounded by RoundingRules.Apply
+	Pages    []PageTotal
+}
+
+type ExamTotal struct {
+	Total
+	UUID       string
+	CourseCode string
+	Diet       string
+	Scripts    []ScriptTotal
+}
+
+// TotalQuestion totals one question, and its parts
+func TotalQuestion(q QuestionDetails, source MarkSource) QuestionTotal {
+	return totalQuestion(&validator{}, "", q, source)
+}
+
+// TotalPage totals the questions on one record
+func TotalPage(pd PageData, source MarkSource) PageTotal {
+
+	v := &validator{}
+
+	pt := PageTotal{
+		PageUUID: pd.Page.UUID,
+		Number:   pd.Page.Number,
+		Revision: pd.Revision,
+	}
+
+	for i, q := range pd.Questions {
+		qt := totalQuestion(v, fmt.Sprintf("/questions/%d", i), q, source)
+		pt.Total.add(qt.Total)
+		pt.Questions = append(pt.Questions, qt)
+	}
+
+	pt.Problems = v.problems
+
+	return pt
+}
+
+// TotalScripts totals each script in a collection of records, such as
+// all those found in a batch of pdfs. Only the latest revision of each
+// page counts. Scripts are in order of exam, then author, and their
+// pages in page order.
+func TotalScripts(pds []PageData, source MarkSource) []ScriptTotal {
+
+	type scriptKey struct {
+		exam   string
+		author string
+	}
+
+	scripts := make(map[scriptKey]*ScriptTotal)
+
+	for _, pd := range latestPages(pds) {
+
+		key := scriptKey{pd.Exam.UUID, scriptAuthor(pd.Author)}
+
+		st, ok := scripts[key]
+		if !ok {
+			st = &ScriptTotal{ExamUUID: key.exam, Author: key.author}
+			scripts[key] = st
+		}
+
+		pt := TotalPage(pd, source)
+		st.Total.add(pt.Total)
+		st.Rounded = st.Awarded
+		st.Pages = append(st.Pages, pt)
+	}
+
+	var sts []ScriptTotal
+
+	for _, st := range scripts {
+		sort.SliceStable(st.Pages, func(i, j int) bool {
+			return st.Pages[i].Number < st.Pages[j].Number
+		})
+		sts = append(sts, *st)
+	}
+
+	sort.Slice(sts, func(i, j int) bool {
+		if sts[i].ExamUUID != sts[j].ExamUUID {
+			return sts[i].ExamUUID < sts[j].ExamUUID
+		}
+		return sts[i].Author < sts[j].Author
+	})
+
+	return sts
+}
+
+// TotalExams totals each exam in a collection of records, script by
+// script, in order of exam UUID
+func TotalExams(pds []PageData, source MarkSource) []ExamTotal {
+
+	exams := make(map[string]*ExamTotal)
+	var order []string
+
+	for _, pd := range pds {
+		if _, ok := exams[pd.Exam.UUID]; !ok {
+			exams[pd.Exam.UUID] = &ExamTotal{
+				UUID:       pd.Exam.UUID,
+				CourseCode: pd.Exam.CourseCode,
+				Diet:       pd.Exam.Diet,
+			}
+			order = append(order, pd.Exam.UUID)
+		}
+	}
+
+	for _, st := range TotalScripts(pds, source) {
+		et := exams[st.ExamUUID]
+		et.Total.add(st.Total)
+		et.Scripts = append(et.Scripts, st)
+	}
+
+	sort.Strings(order)
+
+	var ets []ExamTotal
+	for _, uuid := range order {
+		ets = append(ets, *exams[uuid])
+	}
+
+	return ets
+}
+
+// latestPages keeps the latest revision of each page. Records without a
+// page UUID, from before they were given one, are matched on their exam,
+// author and page number instead. Those without a page number either
+// can't be matched up, so are each kept.
+func latestPages(pds []PageData) []PageData {
+
+	byPage := make(map[string][]PageData)
+	var order []string
+	var latest []PageData
+
+	for _, pd := range pds {
+
+		key := "uuid:" + pd.Page.UUID
+
+		if pd.Page.UUID == "" {
+			if pd.Page.Number == 0 {
+				latest = append(latest, pd)
+				continue
+			}
+			key = fmt.Sprintf("page:%s|%s|%d", pd.Exam.UUID, scriptAuthor(pd.Author), pd.Page.Number)
+		}
+
+		if _, ok := byPage[key]; !ok {
+			order = append(order, key)
+		}
+		byPage[key] = append(byPage[key], pd)
+	}
+
+	for _, key := range order {
+		if pd, err := NewHistory(byPage[key]).Latest(); err == nil {
+			latest = append(latest, pd)
+		}
+	}
+
+	return latest
+}
+
+func scriptAuthor(ad AuthorDetails) string {
+	if ad.Anonymous != "" {
+		return ad.Anonymous
+	}
+	return ad.Identity
+}
+
+func totalQuestion(v *validator, path string, q QuestionDetails, source MarkSource) QuestionTotal {
+
+	qt := QuestionTotal{
+		UUID:    q.UUID,
+		Name:    q.Name,
+		Section: q.Section,
+		Number:  q.Number,
+	}
+
+	given, from, marked := questionMark(q, source)
+	qt.From = from
+
+	if len(q.Parts) == 0 {
+
+		qt.Available = q.MarksAvailable
+
+		if marked {
+			qt.Awarded = given
+			qt.Marked = 1
+		} else {
+			qt.Unmarked = 1
+		}
+
+		return qt
+	}
+
+	var parts Total
+
+	for i, part := range q.Parts {
+		pt := totalQuestion(v, fmt.Sprintf("%s/parts/%d", path, i), part, source)
+		parts.add(pt.Total)
+		qt.Parts = append(qt.Parts, pt)
+	}
+
+	qt.Total = parts
+
+	if !q.MarksAvailable.IsZero() && q.MarksAvailable != parts.Available {
+		v.add(SeverityWarning, path+"/marksAvailable",
+			"%v available, but its parts total %v", q.MarksAvailable, parts.Available)
+	}
+
+	if parts.Available.IsZero() {
+		qt.Available = q.MarksAvailable
+	}
+
+	if !marked {
+		return qt
+	}
+
+	if parts.Marked == 0 {
+		// only the whole question was marked
+		qt.Awarded = given
+		qt.Marked += qt.Unmarked
+		qt.Unmarked = 0
+		return qt
+	}
+
+	if given != parts.Awarded {
+		v.add(SeverityWarning, path,
+			"%v awarded by %s, but its parts total %v", given, from, parts.Awarded)
+	}
+
+	return qt
+}
+
+// questionMark is the question's own mark, and where it came from
+func questionMark(q QuestionDetails, source MarkSource) (Mark, MarkSource, bool) {
+
+	if source == AwardedMarks {
+		return q.MarksAwarded, AwardedMarks, true
+	}
+
+	var stages []markingStage
+
+	for _, stage := range markingStages(q) {
+		if source == LatestMarks || source == stage.source {
+			stages = append(stages, stage)
+		}
+	}
+
+	if given, from, ok := latestActionMark(stages); ok {
+		return given, from, true
+	}
+
+	if source != LatestMarks {
+		return Mark{}, source, false
+	}
+
+	// legacy records have only MarksAwarded, but zero could be unmarked
+	if !q.MarksAwarded.IsZero() {
+		return q.MarksAwarded, AwardedMarks, true
+	}
+
+	return Mark{}, LatestMarks, false
+}
+
+// markingStage is one of a question's lists of marking actions
+type markingStage struct {
+	source  MarkSource
+	actions []MarkingAction
+}
+
+// markingStages are in the order they usually happen, so that a later
+// stage wins a tie
+func markingStages(q QuestionDetails) []markingStage {
+	return []markingStage{
+		markingStage{MarkingMarks, q.Marking},
+		markingStage{ModeratingMarks, q.Moderating},
+		markingStage{CheckingMarks, q.Checking},
+	}
+}
+
+// latestActionMark is the mark given by the latest done action in any
+// of the stages, and its stage, ordered by HLC if they are all stamped,
+// else by time, then position
+func latestActionMark(stages []markingStage) (Mark, MarkSource, bool) {
+
+	type doneAction struct {
+		MarkingAction
+		source MarkSource
+	}
+
+	var done []doneAction
+
+	for _, stage := range stages {
+		for _, a := range stage.actions {
+			if a.Done {
+				done = append(done, doneAction{a, stage.source})
+			}
+		}
+	}
+
+	if len(done) == 0 {
+		return Mark{}, LatestMarks, false
+	}
+
+	latest := done[latestAction(len(done), func(i int) *MarkingAction { return &done[i].MarkingAction })]
+
+	return latest.Mark.Given, latest.source, true
+}
+
+// latestAction is the index of the latest of n (> 0) actions, by HLC if
+// they are all stamped, else by time; of those that tie, the last
+func latestAction(n int, action func(i int) *MarkingAction) int {
+
+	useHLC := allStamped(n, func(i int) *HLC { return action(i).HLC })
+
+	latest := 0
+
+	for i := 1; i < n; i++ {
+		a, l := action(i), action(latest)
+		if useHLC {
+			if a.HLC.Compare(*l.HLC) >= 0 {
+				latest = i
+			}
+		} else if a.UnixTime >= l.UnixTime {
+			latest = i
+		}
+	}
+
+	return latest
+}
+
+// Apply rounds each script's total by its exam's rule. The totals
+// themselves stay exact.
+func (rr RoundingRules) Apply(ets []ExamTotal) {
+
+	for i := range ets {
+
+		r, ok := rr.For(ets[i].UUID, ets[i].CourseCode)
+
+		for j := range ets[i].Scripts {
+			st := &ets[i].Scripts[j]
+			st.Rounded = st.Awarded
+			if ok {
+				st.Rounded = r.Round(st.Awarded)
+			}
+		}
+	}
+}
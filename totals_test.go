@@ -0,0 +1,208 @@
+package pdfpagedata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func doneMark(given float64, unixTime int64) MarkingAction {
+	return MarkingAction{Mark: MarkDetails{Given: NewMark(given)}, Done: true, UnixTime: unixTime}
+}
+
+func TestTotalQuestionSources(t *testing.T) {
+
+	q := QuestionDetails{
+		Name:           "1",
+		MarksAvailable: NewMark(10),
+		MarksAwarded:   NewMark(4),
+		Marking:        []MarkingAction{doneMark(5, 1), doneMark(6, 3), MarkingAction{Mark: MarkDetails{Given: NewMark(9)}, UnixTime: 4}},
+		Moderating:     []MarkingAction{doneMark(7, 5)},
+	}
+
+	qt := TotalQuestion(q, LatestMarks)
+	assert.Equal(t, NewMark(7.0), qt.Awarded)
+	assert.Equal(t, ModeratingMarks, qt.From)
+	assert.Equal(t, NewMark(10.0), qt.Available)
+	assert.True(t, qt.Complete())
+
+	assert.Equal(t, NewMark(6.0), TotalQuestion(q, MarkingMarks).Awarded)
+	assert.Equal(t, NewMark(4.0), TotalQuestion(q, AwardedMarks).Awarded)
+
+	qt = TotalQuestion(q, CheckingMarks)
+	assert.Equal(t, NewMark(0.0), qt.Awarded)
+	assert.Equal(t, 1, qt.Unmarked)
+
+	// legacy, with only MarksAwarded
+	qt = TotalQuestion(QuestionDetails{MarksAvailable: NewMark(10), MarksAwarded: NewMark(3)}, LatestMarks)
+	assert.Equal(t, NewMark(3.0), qt.Awarded)
+	assert.Equal(t, AwardedMarks, qt.From)
+
+	qt = TotalQuestion(QuestionDetails{MarksAvailable: NewMark(10)}, LatestMarks)
+	assert.False(t, qt.Complete())
+}
+
+func TestTotalQuestionHLC(t *testing.T) {
+
+	a := doneMark(5, 9)
+	a.HLC = &HLC{WallTime: 1}
+	b := doneMark(6, 1)
+	b.HLC = &HLC{WallTime: 2}
+
+	qt := TotalQuestion(QuestionDetails{Marking: []MarkingAction{a, b}}, LatestMarks)
+	assert.Equal(t, NewMark(6.0), qt.Awarded)
+}
+
+func TestTotalQuestionRemarked(t *testing.T) {
+
+	// marked, checked, then marked again after checking
+	q := QuestionDetails{
+		MarksAvailable: NewMark(10),
+		Marking:        []MarkingAction{doneMark(5, 1), doneMark(8, 3)},
+		Checking:       []MarkingAction{doneMark(6, 2)},
+	}
+
+	qt := TotalQuestion(q, LatestMarks)
+	assert.Equal(t, NewMark(8), qt.Awarded)
+	assert.Equal(t, MarkingMarks, qt.From)
+	assert.Equal(t, NewMark(6), TotalQuestion(q, CheckingMarks).Awarded)
+
+	// by HLC, whatever the wall clocks say
+	q.Marking[1].HLC = &HLC{WallTime: 3}
+	q.Marking[0].HLC = &HLC{WallTime: 1}
+	q.Checking[0].HLC = &HLC{WallTime: 4}
+	q.Marking[1].UnixTime = 9
+
+	qt = TotalQuestion(q, LatestMarks)
+	assert.Equal(t, NewMark(6), qt.Awarded)
+	assert.Equal(t, CheckingMarks, qt.From)
+
+	// with no times at all, the later stage wins
+	q = QuestionDetails{
+		Marking:    []MarkingAction{doneMark(5, 0)},
+		Moderating: []MarkingAction{doneMark(6, 0)},
+	}
+	assert.Equal(t, ModeratingMarks, TotalQuestion(q, LatestMarks).From)
+}
+
+func TestTotalPageParts(t *testing.T) {
+
+	pd := PageData{
+		Questions: []QuestionDetails{
+			QuestionDetails{
+				Name:           "1",
+				MarksAvailable: NewMark(10),
+				Marking:        []MarkingAction{doneMark(6, 1)},
+				Parts: []QuestionDetails{
+					QuestionDetails{Section: "a", MarksAvailable: NewMark(4), Marking: []MarkingAction{doneMark(3, 1)}},
+					QuestionDetails{Section: "b", MarksAvailable: NewMark(5), Marking: []MarkingAction{doneMark(2, 1)}},
+				},
+			},
+			QuestionDetails{
+				Name:           "2",
+				MarksAvailable: NewMark(6),
+				Marking:        []MarkingAction{doneMark(4, 1)},
+				Parts: []QuestionDetails{
+					QuestionDetails{Section: "a", MarksAvailable: NewMark(3)},
+					QuestionDetails{Section: "b", MarksAvailable: NewMark(3)},
+				},
+			},
+			QuestionDetails{
+				Name: "3",
+				Parts: []QuestionDetails{
+					QuestionDetails{Section: "a", MarksAvailable: NewMark(2), Marking: []MarkingAction{doneMark(2, 1)}},
+					QuestionDetails{Section: "b", MarksAvailable: NewMark(2)},
+				},
+			},
+		},
+	}
+
+	pt := TotalPage(pd, LatestMarks)
+
+	// parts total 5 of 9
+	assert.Equal(t, NewMark(5.0), pt.Questions[0].Awarded)
+	assert.Equal(t, NewMark(9.0), pt.Questions[0].Available)
+
+	// only the whole question was marked
+	assert.Equal(t, NewMark(4.0), pt.Questions[1].Awarded)
+	assert.Equal(t, NewMark(6.0), pt.Questions[1].Available)
+	assert.Equal(t, 2, pt.Questions[1].Marked)
+	assert.True(t, pt.Questions[1].Complete())
+
+	// part way through marking
+	assert.Equal(t, NewMark(2.0), pt.Questions[2].Awarded)
+	assert.Equal(t, 1, pt.Questions[2].Unmarked)
+
+	assert.Equal(t, Total{Awarded: NewMark(11), Available: NewMark(19), Marked: 5, Unmarked: 1}, pt.Total)
+
+	var paths []string
+	for _, p := range pt.Problems {
+		assert.Equal(t, SeverityWarning, p.Severity)
+		paths = append(paths, p.Path)
+	}
+	assert.Equal(t, []string{"/questions/0/marksAvailable", "/questions/0"}, paths)
+}
+
+func TestTotalScriptsAndExams(t *testing.T) {
+
+	page := func(exam, author, uuid string, number, revision int, given float64) PageData {
+		return PageData{
+			Exam:     ExamDetails{UUID: exam, CourseCode: "C" + exam},
+			Author:   AuthorDetails{Anonymous: author},
+			Page:     PageDetails{UUID: uuid, Number: number},
+			Revision: revision,
+			Questions: []QuestionDetails{
+				QuestionDetails{MarksAvailable: NewMark(10), Marking: []MarkingAction{doneMark(given, 1)}},
+			},
+		}
+	}
+
+	pds := []PageData{
+		page("e2", "B1", "p5", 1, 0, 1),
+		page("e1", "B2", "p3", 1, 0, 5),
+		page("e1", "B1", "p2", 2, 0, 4),
+		page("e1", "B1", "p1", 1, 0, 1),
+		page("e1", "B1", "p1", 1, 1, 3),
+	}
+
+	sts := TotalScripts(pds, LatestMarks)
+	assert.Equal(t, 3, len(sts))
+
+	assert.Equal(t, "e1", sts[0].ExamUUID)
+	assert.Equal(t, "B1", sts[0].Author)
+	assert.Equal(t, NewMark(7.0), sts[0].Awarded)
+	assert.Equal(t, NewMark(20.0), sts[0].Available)
+	assert.Equal(t, 1, sts[0].Pages[0].Revision)
+	assert.Equal(t, 2, sts[0].Pages[1].Number)
+
+	ets := TotalExams(pds, LatestMarks)
+	assert.Equal(t, 2, len(ets))
+	assert.Equal(t, "e1", ets[0].UUID)
+	assert.Equal(t, "Ce1", ets[0].CourseCode)
+	assert.Equal(t, NewMark(12.0), ets[0].Awarded)
+	assert.Equal(t, NewMark(30.0), ets[0].Available)
+	assert.Equal(t, 2, len(ets[0].Scripts))
+	assert.Equal(t, NewMark(1.0), ets[1].Awarded)
+
+	// legacy records have no page UUID, so are matched on exam, author
+	// and page number, and only the latest revision of each counts
+	legacy := []PageData{
+		page("e1", "B1", "", 1, 0, 1),
+		page("e1", "B1", "", 1, 1, 3),
+		page("e1", "B1", "", 1, 2, 2),
+		page("e1", "B1", "", 2, 0, 3),
+		page("e1", "B2", "", 1, 0, 4),
+	}
+
+	sts = TotalScripts(legacy, LatestMarks)
+	if assert.Equal(t, 2, len(sts)) {
+		assert.Equal(t, NewMark(5), sts[0].Awarded)
+		assert.Equal(t, NewMark(20), sts[0].Available)
+		assert.Equal(t, 2, sts[0].Pages[0].Revision)
+		assert.Equal(t, NewMark(4), sts[1].Awarded)
+	}
+
+	// without a page number either, they can't be matched, so all count
+	sts = TotalScripts([]PageData{page("e1", "B1", "", 0, 0, 1), page("e1", "B1", "", 0, 1, 3)}, LatestMarks)
+	assert.Equal(t, NewMark(4), sts[0].Awarded)
+}
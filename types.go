@@ -1,110 +1,174 @@
 package pdfpagedata
 
+import "encoding/json"
+
 type PageData struct {
-	Exam        ExamDetails         `json:"exam"`
-	Author      AuthorDetails       `json:"author"`
-	Page        PageDetails         `json:"page"`
-	Contact     ContactDetails      `json:"contact"`
-	Submission  SubmissionDetails   `json:"submission"`
-	Questions   []QuestionDetails   `json:"questions"`
-	Processing  []ProcessingDetails `json:"processing"`
-	Custom      []CustomDetails     `json:"custom"`
-	Revision    int                 `json:"revision"`
-	PreparedFor string              `json:"preparedfor"`
-	ToDo        string              `json:"todo"`
+	Exam        ExamDetails                `json:"exam" schema:"required"`
+	Author      AuthorDetails              `json:"author"`
+	Page        PageDetails                `json:"page" schema:"required"`
+	Contact     ContactDetails             `json:"contact"`
+	Submission  SubmissionDetails          `json:"submission"`
+	Questions   []QuestionDetails          `json:"questions"`
+	Processing  []ProcessingDetails        `json:"processing"`
+	Custom      []CustomDetails            `json:"custom"`
+	Revision    int                        `json:"revision"`
+	PreparedFor string                     `json:"preparedfor"`
+	ToDo        string                     `json:"todo"`
+	Extra       map[string]json.RawMessage `json:"-"`
 }
 
 // don't use this in anonymous pages
 type SubmissionDetails struct {
-	FilePrefix       string `json:"filePrefix"`
-	OriginalFilename string `json:"originalFilename"`
-	OriginalFormat   string `json:"originalFormat"`
-	NewFilename      string `json:"newFilename"`
-	NewFormat        string `json:"newFormat"`
+	FilePrefix       string                     `json:"filePrefix"`
+	OriginalFilename string                     `json:"originalFilename"`
+	OriginalFormat   string                     `json:"originalFormat"`
+	NewFilename      string                     `json:"newFilename"`
+	NewFormat        string                     `json:"newFormat"`
+	Extra            map[string]json.RawMessage `json:"-"`
 }
 
 type ExamDetails struct {
-	CourseCode string `json:"courseCode"`
-	Diet       string `json:"diet"`
-	Date       string `json:"date"`
-	UUID       string `json:"UUID"`
+	CourseCode string                     `json:"courseCode"`
+	Diet       string                     `json:"diet"`
+	Date       string                     `json:"date"`
+	UUID       string                     `json:"UUID" schema:"required,minLength=1"`
+	Extra      map[string]json.RawMessage `json:"-"`
 }
 
 type AuthorDetails struct {
-	Anonymous string `json:"Anonymous"`
-	Identity  string `json:"Identity"`
+	Anonymous string                     `json:"Anonymous"`
+	Identity  string                     `json:"Identity"`
+	Extra     map[string]json.RawMessage `json:"-"`
 }
 
 type PageDetails struct {
-	UUID     string `json:"UUID"`
-	Number   int    `json:"number"`
-	Of       int    `json:"of"`
-	Filename string `json:"filename"`
+	UUID     string                     `json:"UUID"`
+	Number   int                        `json:"number" schema:"required,minimum=1"`
+	Of       int                        `json:"of" schema:"required,minimum=1"`
+	Filename string                     `json:"filename"`
+	Extra    map[string]json.RawMessage `json:"-"`
 }
 
 type ContactDetails struct {
-	Name    string `json:"name"`
-	UUID    string `json:"UUID"`
-	Email   string `json:"email"`
-	Address string `json:"address"`
+	Name    string                     `json:"name"`
+	UUID    string                     `json:"UUID"`
+	Email   string                     `json:"email"`
+	Address string                     `json:"address"`
+	Extra   map[string]json.RawMessage `json:"-"`
 }
 
 // use section for (a), (b) and number for (i)
 type QuestionDetails struct {
-	UUID           string            `json:"UUID"`
-	Name           string            `json:"name"` //what to call it in a dropbox etc
-	Section        string            `json:"section"`
-	Number         int               `json:"number"` //No Harry Potter Platform 9&3/4 questions
-	Parts          []QuestionDetails `json:"parts"`
-	MarksAvailable float64           `json:"marksAvailable"`
-	MarksAwarded   float64           `json:"marksAwarded"`
-	Marking        []MarkingAction   `json:"markers"`
-	Moderating     []MarkingAction   `json:"moderators"`
-	Checking       []MarkingAction   `json:"checkers"`
-	Sequence       int               `json:"sequence"`
-	UnixTime       int64             `json:"unixTime"`
-	Previous       string            `json:"previous"`
+	UUID           string                     `json:"UUID"`
+	Name           string                     `json:"name"` //what to call it in a dropbox etc
+	Section        string                     `json:"section"`
+	Number         int                        `json:"number"` //No Harry Potter Platform 9&3/4 questions
+	Parts          []QuestionDetails          `json:"parts"`
+	MarksAvailable Mark                       `json:"marksAvailable" schema:"minimum=0"`
+	MarksAwarded   Mark                       `json:"marksAwarded" schema:"minimum=0"`
+	Marking        []MarkingAction            `json:"markers"`
+	Moderating     []MarkingAction            `json:"moderators"`
+	Checking       []MarkingAction            `json:"checkers"`
+	Rubric         []CriterionDetails         `json:"rubric,omitempty"`
+	Sequence       int                        `json:"sequence"`
+	UnixTime       int64                      `json:"unixTime"`
+	Previous       string                     `json:"previous"`
+	PreviousHash   string                     `json:"previousHash,omitempty"`
+	HLC            *HLC                       `json:"hlc,omitempty"`
+	Extra          map[string]json.RawMessage `json:"-"`
 }
 
 type MarkingAction struct {
-	Actor    string         `json:"actor"`
-	Contact  ContactDetails `json:"contact"`
-	Mark     MarkDetails    `json:"mark"`
-	Done     bool           `json:"done"`
-	UnixTime int64          `json:"unixTime"`
-	Custom   CustomDetails  `json:"custom"`
+	Actor        string                     `json:"actor"`
+	Contact      ContactDetails             `json:"contact"`
+	Mark         MarkDetails                `json:"mark"`
+	Done         bool                       `json:"done"`
+	UnixTime     int64                      `json:"unixTime"`
+	Custom       CustomDetails              `json:"custom"`
+	HLC          *HLC                       `json:"hlc,omitempty"`
+	Criteria     []CriterionAward           `json:"criteria,omitempty"`
+	UUID         string                     `json:"UUID,omitempty"`
+	Previous     string                     `json:"previous,omitempty"`
+	PreviousHash string                     `json:"previousHash,omitempty"`
+	Extra        map[string]json.RawMessage `json:"-"`
 }
 
 type MarkDetails struct {
-	Given     float64 `json:"given"`
-	Available float64 `json:"available"`
-	Comment   float64 `json:"comment"`
+	Given     Mark                       `json:"given" schema:"minimum=0"`
+	Available Mark                       `json:"available" schema:"minimum=0"`
+	Comment   float64                    `json:"comment"`
+	Extra     map[string]json.RawMessage `json:"-"`
+}
+
+// CriterionDetails is one line of a question's rubric: what a marker is
+// looking for, and the marks for it. A criterion is either met, for
+// Marks, or has bands, e.g. 0-2 for a weak answer and 3-4 for a strong
+// one, in which case Marks is not used. See rubric.go.
+type CriterionDetails struct {
+	ID         string                     `json:"id" schema:"required,minLength=1"`
+	Descriptor string                     `json:"descriptor"`
+	Marks      Mark                       `json:"marks" schema:"minimum=0"`
+	Bands      []BandDetails              `json:"bands,omitempty"`
+	Extra      map[string]json.RawMessage `json:"-"`
+}
+
+type BandDetails struct {
+	ID         string                     `json:"id" schema:"required,minLength=1"`
+	Descriptor string                     `json:"descriptor"`
+	Min        Mark                       `json:"min" schema:"minimum=0"`
+	Max        Mark                       `json:"max" schema:"minimum=0"`
+	Extra      map[string]json.RawMessage `json:"-"`
+}
+
+// CriterionAward records that a marker awarded a criterion, and for a
+// banded one, which band and the mark within it
+type CriterionAward struct {
+	Criterion string                     `json:"criterion" schema:"required,minLength=1"`
+	Band      string                     `json:"band,omitempty"`
+	Mark      *Mark                      `json:"mark,omitempty"`
+	Extra     map[string]json.RawMessage `json:"-"`
 }
 
 type CustomDetails struct {
-	Key   string `json:"name"`
-	Value string `json:"value"`
+	Key   string                     `json:"name"`
+	Value string                     `json:"value"`
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 type ProcessingDetails struct {
-	UUID       string             `json:"UUID"`
-	Previous   string             `json:"previous"`
-	UnixTime   int64              `json:"unixTime"`
-	Name       string             `json:"name"`
-	Parameters []ParameterDetails `json:"parameters"`
-	By         ContactDetails     `json:"by"`
-	Sequence   int                `json:"sequence"`
+	UUID         string                     `json:"UUID"`
+	Previous     string                     `json:"previous"`
+	UnixTime     int64                      `json:"unixTime"`
+	Name         string                     `json:"name"`
+	Parameters   []ParameterDetails         `json:"parameters"`
+	By           ContactDetails             `json:"by"`
+	Sequence     int                        `json:"sequence"`
+	PreviousHash string                     `json:"previousHash,omitempty"`
+	HLC          *HLC                       `json:"hlc,omitempty"`
+	Extra        map[string]json.RawMessage `json:"-"`
 }
 
 type ParameterDetails struct {
-	Name     string `json:"name"`
-	Value    string `json:"value"`
-	Sequence int    `json:"sequence"`
+	Name     string                     `json:"name"`
+	Value    string                     `json:"value"`
+	Sequence int                        `json:"sequence"`
+	Extra    map[string]json.RawMessage `json:"-"`
+}
+
+// HLC is a hybrid logical clock timestamp: the wall time in nanoseconds,
+// pushed forward past any timestamp already seen, plus a counter for
+// events in the same nanosecond, plus the node, to break exact ties.
+// See Clock.
+type HLC struct {
+	WallTime int64                      `json:"wallTime"`
+	Logical  int                        `json:"logical"`
+	Node     string                     `json:"node"`
+	Extra    map[string]json.RawMessage `json:"-"`
 }
 
 const (
-	StartTag       = "<gradex-pagedata>"
-	EndTag         = "</gradex-pagedata>"
+	StartTag       = "<" + DefaultNamespace + ">"
+	EndTag         = "</" + DefaultNamespace + ">"
 	StartTagOffset = len(StartTag)
 	EndTagOffset   = len(EndTag)
 )
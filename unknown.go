@@ -0,0 +1,558 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// A newer tool may add fields that this version doesn't know about.
+// Each type keeps them in Extra when unmarshalled, and writes them back
+// out when marshalled, so passing a record through an older consumer
+// doesn't lose anything. Strict decoding rejects them instead.
+
+// UnknownFieldsError lists the JSON pointers of fields not in this
+// version's types, found during strict decoding
+type UnknownFieldsError struct {
+	Paths []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("unknown fields: %s", strings.Join(e.Paths, ", "))
+}
+
+// UnknownFields returns the JSON pointers of all the fields held in
+// Extra anywhere in the record, in sorted order
+func UnknownFields(pd PageData) []string {
+
+	var paths []string
+
+	collectUnknown(reflect.ValueOf(pd), "", &paths)
+
+	sort.Strings(paths)
+
+	return paths
+}
+
+func collectUnknown(v reflect.Value, path string, paths *[]string) {
+
+	switch v.Kind() {
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			collectUnknown(v.Elem(), path, paths)
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectUnknown(v.Index(i), fmt.Sprintf("%s/%d", path, i), paths)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Name == "Extra" {
+				for key := range v.Field(i).Interface().(map[string]json.RawMessage) {
+					*paths = append(*paths, path+"/"+escapePointer(key))
+				}
+				continue
+			}
+			name, ok := jsonName(field)
+			if !ok {
+				continue
+			}
+			collectUnknown(v.Field(i), path+"/"+escapePointer(name), paths)
+		}
+	}
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// jsonName returns the name a struct field has in JSON
+func jsonName(field reflect.StructField) (string, bool) {
+
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// unmarshalWithExtra decodes into plain, which must be a pointer to a
+// struct type without its own UnmarshalJSON, and returns any fields that
+// don't belong to it. Matching is case insensitive, like encoding/json.
+// The data is read once, in a single pass: nested types that keep their
+// own Extra are filled in as they are reached, rather than by their own
+// UnmarshalJSON decoding their part of the data again.
+func unmarshalWithExtra(data []byte, plain interface{}) (map[string]json.RawMessage, error) {
+
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	v := reflect.ValueOf(plain).Elem()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok != json.Delim('{') {
+		return nil, typeError(dec, tok, v.Type())
+	}
+
+	extra, err := decodeFields(dec, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+
+	return extra, nil
+}
+
+// decodeFields fills the struct v from the object whose { has just
+// been read, up to and including its }, returning the unknown fields
+func decodeFields(dec *json.Decoder, v reflect.Value) (map[string]json.RawMessage, error) {
+
+	var extra map[string]json.RawMessage
+
+	for dec.More() {
+
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key := tok.(string)
+
+		i, ok := fieldFor(v.Type(), key)
+		if !ok {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+			if extra == nil {
+				extra = make(map[string]json.RawMessage)
+			}
+			extra[key] = raw
+			continue
+		}
+
+		if err := decodeValue(dec, v.Field(i)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return extra, nil
+}
+
+// decodeValue reads the next value into v. Structs that keep an Extra,
+// and pointers to and slices of them, are walked here; anything else is
+// decoded as encoding/json would.
+func decodeValue(dec *json.Decoder, v reflect.Value) error {
+
+	t := v.Type()
+
+	if !keepsExtra(elemType(t)) {
+		return dec.Decode(v.Addr().Interface())
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok == nil {
+		// null clears pointers and slices, and leaves structs alone
+		if t.Kind() != reflect.Struct {
+			v.Set(reflect.Zero(t))
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+
+	case reflect.Struct:
+		if tok != json.Delim('{') {
+			return typeError(dec, tok, t)
+		}
+		extra, err := decodeFields(dec, v)
+		if err != nil {
+			return err
+		}
+		v.FieldByName("Extra").Set(reflect.ValueOf(extra))
+		return nil
+
+	case reflect.Ptr:
+		if tok != json.Delim('{') {
+			return typeError(dec, tok, t)
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		extra, err := decodeFields(dec, v.Elem())
+		if err != nil {
+			return err
+		}
+		v.Elem().FieldByName("Extra").Set(reflect.ValueOf(extra))
+		return nil
+	}
+
+	if tok != json.Delim('[') {
+		return typeError(dec, tok, t)
+	}
+
+	items := reflect.MakeSlice(t, 0, 0)
+
+	for dec.More() {
+		item := reflect.New(t.Elem()).Elem()
+		if err := decodeValue(dec, item); err != nil {
+			return err
+		}
+		items = reflect.Append(items, item)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	v.Set(items)
+
+	return nil
+}
+
+// keepsExtra reports whether t is one of our structs with an Extra
+func keepsExtra(t reflect.Type) bool {
+
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	field, ok := t.FieldByName("Extra")
+
+	return ok && field.Type == reflect.TypeOf(map[string]json.RawMessage{})
+}
+
+// elemType looks through a pointer or slice, and a pointer in a slice
+func elemType(t reflect.Type) reflect.Type {
+
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// fieldFor finds the field a key belongs to, preferring an exact match
+func fieldFor(t reflect.Type, key string) (int, bool) {
+
+	folded := -1
+
+	for i := 0; i < t.NumField(); i++ {
+
+		name, ok := jsonName(t.Field(i))
+		if !ok {
+			continue
+		}
+
+		if name == key {
+			return i, true
+		}
+
+		if folded < 0 && strings.EqualFold(name, key) {
+			folded = i
+		}
+	}
+
+	return folded, folded >= 0
+}
+
+func typeError(dec *json.Decoder, tok json.Token, t reflect.Type) error {
+
+	value := "value"
+
+	switch tok.(type) {
+	case json.Delim:
+		value = "array"
+		if tok == json.Delim('{') {
+			value = "object"
+		}
+	case string:
+		value = "string"
+	case float64:
+		value = "number"
+	case bool:
+		value = "bool"
+	}
+
+	return &json.UnmarshalTypeError{Value: value, Type: t, Offset: dec.InputOffset()}
+}
+
+// marshalWithExtra encodes plain, a struct type without its own
+// MarshalJSON, then appends the extra fields in key order
+func marshalWithExtra(plain interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+
+	data, err := json.Marshal(plain)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+
+	for i, key := range keys {
+
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		// compact so that the value is written exactly as valid JSON
+		var value bytes.Buffer
+		if err := json.Compact(&value, extra[key]); err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value.Bytes())
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (pd *PageData) UnmarshalJSON(data []byte) error {
+	type plain PageData
+	extra, err := unmarshalWithExtra(data, (*plain)(pd))
+	pd.Extra = extra
+	return err
+}
+
+func (pd PageData) MarshalJSON() ([]byte, error) {
+	type plain PageData
+	return marshalWithExtra(plain(pd), pd.Extra)
+}
+
+func (sd *SubmissionDetails) UnmarshalJSON(data []byte) error {
+	type plain SubmissionDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(sd))
+	sd.Extra = extra
+	return err
+}
+
+func (sd SubmissionDetails) MarshalJSON() ([]byte, error) {
+	type plain SubmissionDetails
+	return marshalWithExtra(plain(sd), sd.Extra)
+}
+
+func (ed *ExamDetails) UnmarshalJSON(data []byte) error {
+	type plain ExamDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(ed))
+	ed.Extra = extra
+	return err
+}
+
+func (ed ExamDetails) MarshalJSON() ([]byte, error) {
+	type plain ExamDetails
+	return marshalWithExtra(plain(ed), ed.Extra)
+}
+
+func (ad *AuthorDetails) UnmarshalJSON(data []byte) error {
+	type plain AuthorDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(ad))
+	ad.Extra = extra
+	return err
+}
+
+func (ad AuthorDetails) MarshalJSON() ([]byte, error) {
+	type plain AuthorDetails
+	return marshalWithExtra(plain(ad), ad.Extra)
+}
+
+func (pg *PageDetails) UnmarshalJSON(data []byte) error {
+	type plain PageDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(pg))
+	pg.Extra = extra
+	return err
+}
+
+func (pg PageDetails) MarshalJSON() ([]byte, error) {
+	type plain PageDetails
+	return marshalWithExtra(plain(pg), pg.Extra)
+}
+
+func (cd *ContactDetails) UnmarshalJSON(data []byte) error {
+	type plain ContactDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(cd))
+	cd.Extra = extra
+	return err
+}
+
+func (cd ContactDetails) MarshalJSON() ([]byte, error) {
+	type plain ContactDetails
+	return marshalWithExtra(plain(cd), cd.Extra)
+}
+
+func (q *QuestionDetails) UnmarshalJSON(data []byte) error {
+	type plain QuestionDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(q))
+	q.Extra = extra
+	return err
+}
+
+func (q QuestionDetails) MarshalJSON() ([]byte, error) {
+	type plain QuestionDetails
+	return marshalWithExtra(plain(q), q.Extra)
+}
+
+func (ma *MarkingAction) UnmarshalJSON(data []byte) error {
+	type plain MarkingAction
+	extra, err := unmarshalWithExtra(data, (*plain)(ma))
+	ma.Extra = extra
+	return err
+}
+
+func (ma MarkingAction) MarshalJSON() ([]byte, error) {
+	type plain MarkingAction
+	return marshalWithExtra(plain(ma), ma.Extra)
+}
+
+func (md *MarkDetails) UnmarshalJSON(data []byte) error {
+	type plain MarkDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(md))
+	md.Extra = extra
+	return err
+}
+
+func (md MarkDetails) MarshalJSON() ([]byte, error) {
+	type plain MarkDetails
+	return marshalWithExtra(plain(md), md.Extra)
+}
+
+func (cu *CustomDetails) UnmarshalJSON(data []byte) error {
+	type plain CustomDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(cu))
+	cu.Extra = extra
+	return err
+}
+
+func (cu CustomDetails) MarshalJSON() ([]byte, error) {
+	type plain CustomDetails
+	return marshalWithExtra(plain(cu), cu.Extra)
+}
+
+func (p *ProcessingDetails) UnmarshalJSON(data []byte) error {
+	type plain ProcessingDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(p))
+	p.Extra = extra
+	return err
+}
+
+func (p ProcessingDetails) MarshalJSON() ([]byte, error) {
+	type plain ProcessingDetails
+	return marshalWithExtra(plain(p), p.Extra)
+}
+
+func (pa *ParameterDetails) UnmarshalJSON(data []byte) error {
+	type plain ParameterDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(pa))
+	pa.Extra = extra
+	return err
+}
+
+func (pa ParameterDetails) MarshalJSON() ([]byte, error) {
+	type plain ParameterDetails
+	return marshalWithExtra(plain(pa), pa.Extra)
+}
+
+func (h *HLC) UnmarshalJSON(data []byte) error {
+	type plain HLC
+	extra, err := unmarshalWithExtra(data, (*plain)(h))
+	h.Extra = extra
+	return err
+}
+
+func (h HLC) MarshalJSON() ([]byte, error) {
+	type plain HLC
+	return marshalWithExtra(plain(h), h.Extra)
+}
+
+func (c *CriterionDetails) UnmarshalJSON(data []byte) error {
+	type plain CriterionDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(c))
+	c.Extra = extra
+	return err
+}
+
+func (c CriterionDetails) MarshalJSON() ([]byte, error) {
+	type plain CriterionDetails
+	return marshalWithExtra(plain(c), c.Extra)
+}
+
+func (b *BandDetails) UnmarshalJSON(data []byte) error {
+	type plain BandDetails
+	extra, err := unmarshalWithExtra(data, (*plain)(b))
+	b.Extra = extra
+	return err
+}
+
+func (b BandDetails) MarshalJSON() ([]byte, error) {
+	type plain BandDetails
+	return marshalWithExtra(plain(b), b.Extra)
+}
+
+func (ca *CriterionAward) UnmarshalJSON(data []byte) error {
+	type plain CriterionAward
+	extra, err := unmarshalWithExtra(data, (*plain)(ca))
+	ca.Extra = extra
+	return err
+}
+
+func (ca CriterionAward) MarshalJSON() ([]byte, error) {
+	type plain CriterionAward
+	return marshalWithExtra(plain(ca), ca.Extra)
+}
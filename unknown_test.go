@@ -0,0 +1,177 @@
+package pdfpagedata
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fromNewerTool = `{
+  "exam": {"courseCode": "ENGI12123", "UUID": "e1", "term": "S1"},
+  "page": {"number": 3, "rotation": 90},
+  "questions": [
+    {
+      "name": "Q1",
+      "solution": {"id": "s1"},
+      "parts": [{"name": "Q1a", "difficulty": "hard"}],
+      "markers": [{"actor": "tim", "mark": {"given": 2, "scale": [1, 2]}, "signature": "abc"}]
+    }
+  ],
+  "processing": [
+    {"name": "split", "parameters": [{"name": "dpi", "value": "300", "unit": "dpi"}], "host": "box1"}
+  ],
+  "custom": [{"name": "k", "value": "v", "type": "string"}],
+  "revision": 2,
+  "schema": 5
+}`
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+
+	var pd PageData
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &pd))
+
+	assert.Equal(t, "ENGI12123", pd.Exam.CourseCode)
+	assert.Equal(t, 2, pd.Revision)
+	assert.Equal(t, json.RawMessage(`5`), pd.Extra["schema"])
+	assert.Equal(t, json.RawMessage(`"S1"`), pd.Exam.Extra["term"])
+	assert.Equal(t, json.RawMessage(`"box1"`), pd.Processing[0].Extra["host"])
+
+	assert.Equal(t, []string{
+		"/custom/0/type",
+		"/exam/term",
+		"/page/rotation",
+		"/processing/0/host",
+		"/processing/0/parameters/0/unit",
+		"/questions/0/markers/0/mark/scale",
+		"/questions/0/markers/0/signature",
+		"/questions/0/parts/0/difficulty",
+		"/questions/0/solution",
+		"/schema",
+	}, UnknownFields(pd))
+
+	// older consumer edits something it knows about
+	pd.Revision = 3
+
+	token, err := json.Marshal(&pd)
+	assert.NoError(t, err)
+
+	var original, written map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &original))
+	assert.NoError(t, json.Unmarshal(token, &written))
+
+	assert.Equal(t, 3.0, written["revision"])
+
+	// known fields are all written out, so compare the unknown ones
+	var again PageData
+	assert.NoError(t, json.Unmarshal(token, &again))
+	assert.Equal(t, UnknownFields(pd), UnknownFields(again))
+	assert.Equal(t, original["schema"], written["schema"])
+	assert.Equal(t, original["questions"].([]interface{})[0].(map[string]interface{})["solution"],
+		written["questions"].([]interface{})[0].(map[string]interface{})["solution"])
+
+	// and marshalling by value gives the same
+	byValue, err := json.Marshal(pd)
+	assert.NoError(t, err)
+	assert.Equal(t, string(token), string(byValue))
+}
+
+func TestUnknownFieldNowKnown(t *testing.T) {
+
+	// a field that was unknown, and kept, until this version gave it a
+	// meaning; a record that uses it some other way no longer decodes
+	token := `{"exam":{"courseCode":"ENGI12123"},"questions":[{"name":"Q1","rubric":{"id":"r1"}}]}`
+
+	var pd PageData
+	err := json.Unmarshal([]byte(token), &pd)
+	if te, ok := err.(*json.UnmarshalTypeError); assert.True(t, ok) {
+		assert.Equal(t, "object", te.Value)
+		assert.Equal(t, reflect.TypeOf([]CriterionDetails{}), te.Type)
+	}
+
+	_, err = DecodePageData(token, ReadOptions{})
+	assert.Error(t, err)
+}
+
+func TestUnknownFieldsNone(t *testing.T) {
+
+	pd := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}}
+
+	token, err := json.Marshal(pd)
+	assert.NoError(t, err)
+
+	var out PageData
+	assert.NoError(t, json.Unmarshal(token, &out))
+	assert.Equal(t, pd, out)
+	assert.Nil(t, out.Extra)
+	assert.Equal(t, 0, len(UnknownFields(out)))
+
+	// an empty list is written as it always was, though it hashes the
+	// same as a nil one
+	empty := PageData{Exam: pd.Exam, Questions: []QuestionDetails{}}
+	a, err := json.Marshal(empty)
+	assert.NoError(t, err)
+	assert.Contains(t, string(a), `"questions":[]`)
+	ha, err := empty.Hash()
+	assert.NoError(t, err)
+	hb, err := pd.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, hb, ha)
+
+	// an empty object with extras is still valid JSON
+	data, err := marshalWithExtra(struct{}{}, map[string]json.RawMessage{"a": json.RawMessage(` [1, 2] `)})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":[1,2]}`, string(data))
+}
+
+func TestDecodePageDataStrict(t *testing.T) {
+
+	_, err := DecodePageData(fromNewerTool, ReadOptions{})
+	assert.NoError(t, err)
+
+	_, err = DecodePageData(fromNewerTool, ReadOptions{Strict: true})
+	if assert.Error(t, err) {
+		ue, ok := err.(*UnknownFieldsError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 10, len(ue.Paths))
+		}
+	}
+
+	// field names match case insensitively, like encoding/json
+	pd, err := DecodePageData(`{"Exam":{"coursecode":"X"},"REVISION":1}`, ReadOptions{Strict: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "X", pd.Exam.CourseCode)
+}
+
+func TestUnmarshalWithExtraSinglePass(t *testing.T) {
+
+	// the same as encoding/json would give, for the known fields
+	type plain PageData
+	var pd PageData
+	var want plain
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &pd))
+	assert.NoError(t, json.Unmarshal([]byte(fromNewerTool), &want))
+	assert.Equal(t, want.Questions[0].Parts[0].Name, pd.Questions[0].Parts[0].Name)
+	assert.Equal(t, want.Questions[0].Marking[0].Mark.Given, pd.Questions[0].Marking[0].Mark.Given)
+	assert.Equal(t, want.Processing[0].Parameters[0].Value, pd.Processing[0].Parameters[0].Value)
+
+	// names match case insensitively, and unknown keys are kept as written
+	assert.NoError(t, json.Unmarshal([]byte(`{"hlc":{"WallTime":2,"NODE":"n","Region":"r"}}`), &pd.Processing[0]))
+	assert.Equal(t, int64(2), pd.Processing[0].HLC.WallTime)
+	assert.Equal(t, "n", pd.Processing[0].HLC.Node)
+	assert.Equal(t, json.RawMessage(`"r"`), pd.Processing[0].HLC.Extra["Region"])
+
+	// null clears lists and pointers, and leaves the rest alone
+	assert.NoError(t, json.Unmarshal([]byte(`{"questions":null,"exam":null}`), &pd))
+	assert.Nil(t, pd.Questions)
+	assert.Equal(t, "ENGI12123", pd.Exam.CourseCode)
+	assert.NoError(t, json.Unmarshal([]byte(`{"hlc":null}`), &pd.Processing[0]))
+	assert.Nil(t, pd.Processing[0].HLC)
+	assert.NoError(t, json.Unmarshal([]byte(`{"questions":[]}`), &pd))
+	assert.Equal(t, []QuestionDetails{}, pd.Questions)
+
+	for _, bad := range []string{`{"questions":{}}`, `{"exam":[]}`, `{"questions":[1]}`, `[]`, `{"revision":1}x`, `{"exam":{`} {
+		assert.Error(t, json.Unmarshal([]byte(bad), &pd), bad)
+	}
+}
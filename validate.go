@@ -0,0 +1,186 @@
+package pdfpagedata
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validate goes beyond the shape of the record (see ValidateSchema) to
+// check that it makes sense, e.g. no more marks awarded than available.
+// Problems that mean the record is wrong are errors; those that are only
+// suspicious, like parts not adding up, are warnings; those that are
+// worth knowing but harmless, like a step not named after its workflow
+// transition, are info.
+
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota
+	SeverityWarning Severity = iota
+	SeverityError   Severity = iota
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Problem is one thing wrong with a record, at Path, a JSON pointer
+type Problem struct {
+	Severity Severity
+	Path     string
+	Message  string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s %s: %s", p.Severity, p.Path, p.Message)
+}
+
+// ValidationError is returned when writing a record that has errors
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+
+	var msgs []string
+
+	for _, p := range e.Problems {
+		msgs = append(msgs, p.String())
+	}
+
+	return fmt.Sprintf("invalid page data: %s", strings.Join(msgs, "; "))
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Validate returns every problem found in the record, in the order the
+// fields appear. Empty UUIDs are not checked; the schema requires those
+// that must be present.
+func Validate(pd PageData) []Problem {
+
+	v := &validator{}
+
+	v.uuid("/exam/UUID", pd.Exam.UUID)
+	v.uuid("/page/UUID", pd.Page.UUID)
+	v.uuid("/contact/UUID", pd.Contact.UUID)
+
+	for i, q := range pd.Questions {
+		v.question(fmt.Sprintf("/questions/%d", i), q)
+	}
+
+	sequences := make(map[int]int)
+	seqs := StepSequences(pd.Processing)
+
+	for i, pr := range pd.Processing {
+
+		path := fmt.Sprintf("/processing/%d", i)
+
+		v.uuid(path+"/UUID", pr.UUID)
+		v.uuid(path+"/previous", pr.Previous)
+		v.uuid(path+"/by/UUID", pr.By.UUID)
+
+		// as renumbered by any merge
+		if first, dup := sequences[seqs[i]]; dup {
+			v.add(SeverityError, path+"/sequence",
+				"sequence %d already used by /processing/%d", seqs[i], first)
+		} else {
+			sequences[seqs[i]] = i
+		}
+	}
+
+	return v.problems
+}
+
+// HasErrors reports whether any of the problems is an error
+func HasErrors(problems []Problem) bool {
+
+	for _, p := range problems {
+		if p.Severity >= SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(severity Severity, path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Severity: severity,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) uuid(path, uuid string) {
+	if uuid != "" && !uuidRegexp.MatchString(uuid) {
+		v.add(SeverityError, path, "%q is not a well-formed UUID", uuid)
+	}
+}
+
+func (v *validator) question(path string, q QuestionDetails) {
+
+	v.uuid(path+"/UUID", q.UUID)
+	v.uuid(path+"/previous", q.Previous)
+
+	if q.MarksAwarded.Cmp(q.MarksAvailable) > 0 {
+		v.add(SeverityError, path+"/marksAwarded",
+			"%v awarded but only %v available", q.MarksAwarded, q.MarksAvailable)
+	}
+
+	if len(q.Parts) > 0 {
+
+		var available, awarded Mark
+		for _, part := range q.Parts {
+			available = available.Add(part.MarksAvailable)
+			awarded = awarded.Add(part.MarksAwarded)
+		}
+
+		if available != q.MarksAvailable {
+			v.add(SeverityWarning, path+"/marksAvailable",
+				"parts have %v available in total, not %v", available, q.MarksAvailable)
+		}
+
+		if awarded != q.MarksAwarded {
+			v.add(SeverityWarning, path+"/marksAwarded",
+				"parts were awarded %v in total, not %v", awarded, q.MarksAwarded)
+		}
+	}
+
+	for i, part := range q.Parts {
+		v.question(fmt.Sprintf("%s/parts/%d", path, i), part)
+	}
+
+	v.actions(path+"/markers", q.Marking)
+	v.actions(path+"/moderators", q.Moderating)
+	v.actions(path+"/checkers", q.Checking)
+
+	v.rubric(path, q)
+}
+
+func (v *validator) actions(path string, actions []MarkingAction) {
+
+	for i, a := range actions {
+
+		p := fmt.Sprintf("%s/%d", path, i)
+
+		v.uuid(p+"/contact/UUID", a.Contact.UUID)
+
+		if a.Mark.Given.Cmp(a.Mark.Available) > 0 {
+			v.add(SeverityError, p+"/mark/given",
+				"%v given but only %v available", a.Mark.Given, a.Mark.Available)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package pdfpagedata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+)
+
+func TestValidateGood(t *testing.T) {
+
+	pd := validSchemaPageData()
+	pd.Questions[0].Parts = []QuestionDetails{
+		QuestionDetails{Name: "Q1a", MarksAvailable: NewMark(5), MarksAwarded: NewMark(2)},
+		QuestionDetails{Name: "Q1b", MarksAvailable: NewMark(2.5), MarksAwarded: NewMark(0.25)},
+	}
+	pd.Processing = append(pd.Processing, ProcessingDetails{Name: "mark", Sequence: 2})
+
+	assert.Empty(t, Validate(pd))
+}
+
+func TestValidateProblems(t *testing.T) {
+
+	pd := validSchemaPageData()
+	pd.Exam.UUID = "not-a-uuid"
+	pd.Questions[0].MarksAwarded = NewMark(8)
+	pd.Questions[0].Parts = []QuestionDetails{
+		QuestionDetails{Name: "Q1a", MarksAvailable: NewMark(5), MarksAwarded: NewMark(2)},
+	}
+	pd.Questions[0].Marking[0].Mark = MarkDetails{Given: NewMark(3), Available: NewMark(2)}
+	pd.Processing = append(pd.Processing, ProcessingDetails{Name: "again", Sequence: 1})
+
+	problems := Validate(pd)
+
+	assert.Equal(t, []Problem{
+		Problem{SeverityError, "/exam/UUID", `"not-a-uuid" is not a well-formed UUID`},
+		Problem{SeverityError, "/questions/0/marksAwarded", "8 awarded but only 7.5 available"},
+		Problem{SeverityWarning, "/questions/0/marksAvailable", "parts have 5 available in total, not 7.5"},
+		Problem{SeverityWarning, "/questions/0/marksAwarded", "parts were awarded 2 in total, not 8"},
+		Problem{SeverityError, "/questions/0/markers/0/mark/given", "3 given but only 2 available"},
+		Problem{SeverityError, "/processing/1/sequence", "sequence 1 already used by /processing/0"},
+	}, problems)
+
+	assert.True(t, HasErrors(problems))
+	assert.False(t, HasErrors(problems[2:4]))
+	assert.Equal(t, "warning /questions/0/marksAvailable: parts have 5 available in total, not 7.5", problems[2].String())
+}
+
+func TestMarshalRefusesInvalid(t *testing.T) {
+
+	pd := validSchemaPageData()
+	pd.Questions[0].MarksAwarded = NewMark(8)
+
+	c := creator.New()
+	c.NewPage()
+
+	err := MarshalPageDataWithOptions(c, &pd, WriteOptions{Validate: true})
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Equal(t, 1, len(err.(*ValidationError).Problems))
+	}
+
+	// only warnings, so written
+	pd = validSchemaPageData()
+	pd.Questions[0].Parts = []QuestionDetails{QuestionDetails{Name: "Q1a"}}
+	assert.NoError(t, MarshalPageDataWithOptions(c, &pd, WriteOptions{Validate: true}))
+}
@@ -0,0 +1,301 @@
+package pdfpagedata
+
+import (
+	"errors"
+	"fmt"
+)
+
+// A page moves through the states Raw .. Checked as it is marked,
+// moderated and checked. Each move is recorded as a processing step,
+// with the states it went from and to as parameters, so the current
+// state is the destination of the last such step. Records from before
+// this was recorded have their state worked out from the marking
+// actions on their questions instead. Other workflows, with their own
+// states, can be loaded from a definition (see workflowdef.go).
+
+const (
+	StateFromParameter = "workflow-from"
+	StateToParameter   = "workflow-to"
+	RoleParameter      = "workflow-role"
+)
+
+var ErrUnknownState = errors.New("unknown workflow state")
+
+var stateNames = []string{
+	Raw:             "raw",
+	ReadyToMark:     "ready-to-mark",
+	Marked:          "marked",
+	ReadyToModerate: "ready-to-moderate",
+	Moderated:       "moderated",
+	ReadyToCheck:    "ready-to-check",
+	Checked:         "checked",
+}
+
+// StateName is the name of a state in DefaultWorkflow
+func StateName(state int) string {
+	return DefaultWorkflow.StateName(state)
+}
+
+// ParseState is the state in DefaultWorkflow with this name
+func ParseState(name string) (int, error) {
+	return DefaultWorkflow.ParseState(name)
+}
+
+// Transition is an allowed move between states, recorded as a
+// processing step with this name. If Roles is not empty, only those
+// roles may make it. A LoopBack goes back to an earlier state, e.g. to
+// mark again after checking.
+type Transition struct {
+	Name     string
+	From     int
+	To       int
+	Roles    []string
+	LoopBack bool
+}
+
+// Workflow is a set of states, numbered by their position in States,
+// and the allowed transitions between them
+type Workflow struct {
+	Name        string
+	States      []string
+	Initial     int
+	Transitions []Transition
+
+	// work out the state of legacy records from their questions
+	inferFromQuestions bool
+}
+
+// DefaultWorkflow goes in order from Raw to Checked, except that
+// moderation can be skipped, since usually only a sample is moderated
+var DefaultWorkflow = &Workflow{
+	Name:               "default",
+	States:             stateNames,
+	Initial:            Raw,
+	inferFromQuestions: true,
+	Transitions: []Transition{
+		Transition{Name: "ready-to-mark", From: Raw, To: ReadyToMark},
+		Transition{Name: "mark", From: ReadyToMark, To: Marked},
+		Transition{Name: "ready-to-moderate", From: Marked, To: ReadyToModerate},
+		Transition{Name: "moderate", From: ReadyToModerate, To: Moderated},
+		Transition{Name: "ready-to-check", From: Moderated, To: ReadyToCheck},
+		Transition{Name: "ready-to-check", From: Marked, To: ReadyToCheck},
+		Transition{Name: "check", From: ReadyToCheck, To: Checked},
+	},
+}
+
+// TransitionError is returned for a move the workflow doesn't allow
+type TransitionError struct {
+	From     int
+	To       int
+	Role     string
+	workflow *Workflow
+}
+
+func (e *TransitionError) Error() string {
+
+	w := e.workflow
+	if w == nil {
+		w = DefaultWorkflow
+	}
+
+	if e.Role != "" {
+		return fmt.Sprintf("role %s cannot go from %s to %s", e.Role, w.StateName(e.From), w.StateName(e.To))
+	}
+
+	return fmt.Sprintf("cannot go from %s to %s", w.StateName(e.From), w.StateName(e.To))
+}
+
+// StateName is the name of a state, as recorded in processing steps
+func (w *Workflow) StateName(state int) string {
+
+	if state < 0 || state >= len(w.States) {
+		return fmt.Sprintf("state(%d)", state)
+	}
+
+	return w.States[state]
+}
+
+// ParseState is the state with this name
+func (w *Workflow) ParseState(name string) (int, error) {
+
+	for state, n := range w.States {
+		if n == name {
+			return state, nil
+		}
+	}
+
+	return 0, ErrUnknownState
+}
+
+// Allowed returns the transition from one state to another, if allowed
+func (w *Workflow) Allowed(from, to int) (Transition, bool) {
+
+	for _, t := range w.Transitions {
+		if t.From == from && t.To == to {
+			return t, true
+		}
+	}
+
+	return Transition{}, false
+}
+
+// Next lists the states that can be moved to from this one
+func (w *Workflow) Next(from int) []int {
+
+	var next []int
+
+	for _, t := range w.Transitions {
+		if t.From == from {
+			next = append(next, t.To)
+		}
+	}
+
+	return next
+}
+
+// State is the page's current state
+func (w *Workflow) State(pd PageData) int {
+
+	hasState := func(step ProcessingDetails) bool {
+		_, ok := w.stepState(step)
+		return ok
+	}
+
+	if last, ok := lastStep(pd.Processing, hasState); ok {
+		state, _ := w.stepState(pd.Processing[last])
+		return state
+	}
+
+	if w.inferFromQuestions {
+		return inferState(pd)
+	}
+
+	return w.Initial
+}
+
+// Transition returns the next revision of pd, moved to the state to, and
+// a *TransitionError if the workflow doesn't allow that. The move is
+// recorded as a processing step by by; stamp it with an HLC by passing
+// a clock.
+func (w *Workflow) Transition(pd PageData, to int, by ContactDetails, clock *Clock) (PageData, error) {
+	return w.TransitionAs(pd, to, "", by, clock)
+}
+
+// TransitionAs is Transition, for someone acting in a role, which
+// is recorded too. Transitions limited to certain roles need one.
+func (w *Workflow) TransitionAs(pd PageData, to int, role string, by ContactDetails, clock *Clock) (PageData, error) {
+
+	from := w.State(pd)
+
+	t, ok := w.Allowed(from, to)
+	if !ok {
+		return pd, &TransitionError{From: from, To: to, workflow: w}
+	}
+
+	if !t.permits(role) {
+		return pd, &TransitionError{From: from, To: to, Role: roleOrNone(role), workflow: w}
+	}
+
+	params := []ParameterDetails{
+		ParameterDetails{Name: StateFromParameter, Value: w.StateName(from)},
+		ParameterDetails{Name: StateToParameter, Value: w.StateName(to)},
+	}
+
+	if role != "" {
+		params = append(params, ParameterDetails{Name: RoleParameter, Value: role})
+	}
+
+	b := UpdateBuilder(pd)
+	if clock != nil {
+		b = b.WithHLC(clock)
+	}
+
+	return b.Process(t.Name, by, params...).Build(), nil
+}
+
+func (t Transition) permits(role string) bool {
+
+	if len(t.Roles) == 0 {
+		return true
+	}
+
+	for _, r := range t.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func roleOrNone(role string) string {
+	if role == "" {
+		return "(none)"
+	}
+	return role
+}
+
+// stepState is the state a processing step moved the page to, if any
+func (w *Workflow) stepState(step ProcessingDetails) (int, bool) {
+
+	value, ok := stepParameter(step, StateToParameter)
+	if !ok {
+		return 0, false
+	}
+
+	state, err := w.ParseState(value)
+
+	return state, err == nil
+}
+
+func stepParameter(step ProcessingDetails, name string) (string, bool) {
+
+	for _, p := range step.Parameters {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// inferState works out the state of a page with no recorded transitions
+// from how far the marking of its questions has got
+func inferState(pd PageData) int {
+
+	if len(pd.Questions) == 0 {
+		return Raw
+	}
+
+	switch {
+	case allDone(pd.Questions, func(q QuestionDetails) []MarkingAction { return q.Checking }):
+		return Checked
+	case allDone(pd.Questions, func(q QuestionDetails) []MarkingAction { return q.Moderating }):
+		return Moderated
+	case allDone(pd.Questions, func(q QuestionDetails) []MarkingAction { return q.Marking }):
+		return Marked
+	}
+
+	return Raw
+}
+
+// allDone reports whether every question has at least one action of
+// this kind, and they are all done
+func allDone(questions []QuestionDetails, actions func(QuestionDetails) []MarkingAction) bool {
+
+	for _, q := range questions {
+
+		as := actions(q)
+		if len(as) == 0 {
+			return false
+		}
+
+		for _, a := range as {
+			if !a.Done {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,160 @@
+package pdfpagedata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timdrysdale/unipdf/v3/creator"
+)
+
+func TestStateNames(t *testing.T) {
+
+	for state := Raw; state <= Checked; state++ {
+		parsed, err := ParseState(StateName(state))
+		assert.NoError(t, err)
+		assert.Equal(t, state, parsed)
+	}
+
+	_, err := ParseState("lost")
+	assert.Equal(t, ErrUnknownState, err)
+	assert.Equal(t, "state(99)", StateName(99))
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+
+	w := DefaultWorkflow
+	marker := ContactDetails{Name: "marker"}
+
+	pd := NewBuilder().Page(1, 1).Build()
+	assert.Equal(t, Raw, w.State(pd))
+
+	// no jumping ahead
+	_, err := w.Transition(pd, Checked, marker, nil)
+	assert.Equal(t, &TransitionError{From: Raw, To: Checked, workflow: DefaultWorkflow}, err)
+	assert.Equal(t, "cannot go from raw to checked", err.Error())
+
+	for _, to := range []int{ReadyToMark, Marked, ReadyToCheck, Checked} {
+		next, err := w.Transition(pd, to, marker, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, to, w.State(next))
+		assert.Equal(t, pd.Revision+1, next.Revision)
+		pd = next
+	}
+
+	assert.Equal(t, 4, len(pd.Processing))
+	last := pd.Processing[3]
+	assert.Equal(t, "check", last.Name)
+	assert.Equal(t, []ParameterDetails{
+		ParameterDetails{Name: StateFromParameter, Value: "ready-to-check", Sequence: 1},
+		ParameterDetails{Name: StateToParameter, Value: "checked", Sequence: 2},
+	}, last.Parameters)
+	assert.Equal(t, pd.Processing[2].UUID, last.Previous)
+
+	assert.Empty(t, w.Next(Checked))
+	assert.Equal(t, []int{ReadyToModerate, ReadyToCheck}, w.Next(Marked))
+}
+
+func TestWorkflowStampsHLC(t *testing.T) {
+
+	pd, err := DefaultWorkflow.Transition(PageData{}, ReadyToMark, ContactDetails{}, NewClock("n"))
+	assert.NoError(t, err)
+	assert.NotNil(t, pd.Processing[0].HLC)
+}
+
+func TestInferState(t *testing.T) {
+
+	done := []MarkingAction{MarkingAction{Done: true}}
+	pending := []MarkingAction{MarkingAction{Done: false}}
+
+	pd := PageData{Questions: []QuestionDetails{
+		QuestionDetails{Marking: done},
+		QuestionDetails{Marking: pending},
+	}}
+	assert.Equal(t, Raw, DefaultWorkflow.State(pd))
+
+	pd.Questions[1].Marking = done
+	assert.Equal(t, Marked, DefaultWorkflow.State(pd))
+
+	pd.Questions[0].Moderating = done
+	pd.Questions[1].Moderating = done
+	assert.Equal(t, Moderated, DefaultWorkflow.State(pd))
+
+	pd.Questions[0].Checking = done
+	pd.Questions[1].Checking = done
+	assert.Equal(t, Checked, DefaultWorkflow.State(pd))
+
+	// a recorded transition wins
+	pd.Processing = []ProcessingDetails{ProcessingDetails{
+		Name:       "ready-to-moderate",
+		Parameters: []ParameterDetails{ParameterDetails{Name: StateToParameter, Value: "ready-to-moderate"}},
+	}}
+	assert.Equal(t, ReadyToModerate, DefaultWorkflow.State(pd))
+}
+
+func TestTriagePdfState(t *testing.T) {
+
+	ready, err := DefaultWorkflow.Transition(NewBuilder().Build(), ReadyToMark, ContactDetails{}, nil)
+	assert.NoError(t, err)
+	marked, err := DefaultWorkflow.Transition(NewBuilder().Build(), ReadyToMark, ContactDetails{}, nil)
+	assert.NoError(t, err)
+	marked, err = DefaultWorkflow.Transition(marked, Marked, ContactDetails{}, nil)
+	assert.NoError(t, err)
+
+	// the pages are in different states, and the last has no page data
+	c := creator.New()
+	c.NewPage()
+	assert.NoError(t, MarshalPageData(c, &marked))
+	c.NewPage()
+	assert.NoError(t, MarshalPageData(c, &ready))
+	c.NewPage()
+
+	dir, err := ioutil.TempDir("", "pdfpagedata")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "triage.pdf")
+	assert.NoError(t, c.WriteToFile(path))
+
+	summary, err := TriagePdf(path)
+	assert.NoError(t, err)
+	assert.True(t, summary.Found)
+	assert.Equal(t, ReadyToMark, summary.State)
+	assert.Equal(t, map[int]int{0: Marked, 1: ReadyToMark}, summary.PageStates)
+
+	c = creator.New()
+	c.NewPage()
+	path = filepath.Join(dir, "blank.pdf")
+	assert.NoError(t, c.WriteToFile(path))
+
+	summary, err = TriagePdf(path)
+	assert.NoError(t, err)
+	assert.False(t, summary.Found)
+	assert.Nil(t, summary.PageStates)
+}
+
+func TestTriage(t *testing.T) {
+
+	raw := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}, PreparedFor: "tim"}
+	checked := PageData{Exam: ExamDetails{CourseCode: "ENGI12123"}, Revision: 1}
+	checked.Processing = []ProcessingDetails{ProcessingDetails{
+		Name:       "check",
+		Parameters: []ParameterDetails{ParameterDetails{Name: StateToParameter, Value: "checked"}},
+	}}
+
+	// whichever order the pages come in, the one furthest behind counts
+	for i := 0; i < 10; i++ {
+		summary, err := triage(map[int][]PageData{0: []PageData{raw}, 1: nil, 2: []PageData{raw, checked}})
+		assert.NoError(t, err)
+		assert.True(t, summary.Found)
+		assert.Equal(t, Raw, summary.State)
+		assert.Equal(t, "tim", summary.PreparedFor)
+		assert.Equal(t, map[int]int{0: Raw, 2: Checked}, summary.PageStates)
+	}
+
+	summary, err := triage(map[int][]PageData{0: nil})
+	assert.NoError(t, err)
+	assert.Equal(t, PdfSummary{}, summary)
+}
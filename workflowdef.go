@@ -0,0 +1,262 @@
+package pdfpagedata
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Courses that don't follow DefaultWorkflow can declare their own, in
+// YAML (or JSON, which is YAML too), e.g. to mark again after checking:
+//
+//	name: remark-after-check
+//	initial: raw
+//	states: [raw, ready-to-mark, marked, ready-to-check, checked]
+//	transitions:
+//	  - {name: ready-to-mark, from: raw, to: ready-to-mark}
+//	  - {name: mark, from: ready-to-mark, to: marked, roles: [marker]}
+//	  - {name: ready-to-check, from: marked, to: ready-to-check}
+//	  - {name: check, from: ready-to-check, to: checked, roles: [checker]}
+//	  - {name: remark, from: checked, to: ready-to-mark, roles: [checker], loopBack: true}
+//
+// States are listed in the order a page normally goes through them, so
+// a transition to an earlier state must be marked as a loop-back, which
+// catches a definition with its states in the wrong order.
+
+// WorkflowDefinition is the file form of a Workflow
+type WorkflowDefinition struct {
+	Name        string                 `json:"name" yaml:"name"`
+	Initial     string                 `json:"initial" yaml:"initial"`
+	States      []string               `json:"states" yaml:"states"`
+	Transitions []TransitionDefinition `json:"transitions" yaml:"transitions"`
+}
+
+type TransitionDefinition struct {
+	Name     string   `json:"name" yaml:"name"`
+	From     string   `json:"from" yaml:"from"`
+	To       string   `json:"to" yaml:"to"`
+	Roles    []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+	LoopBack bool     `json:"loopBack,omitempty" yaml:"loopBack,omitempty"`
+}
+
+// WorkflowDefinitionError lists everything wrong with a definition
+type WorkflowDefinitionError struct {
+	Problems []string
+}
+
+func (e *WorkflowDefinitionError) Error() string {
+	return fmt.Sprintf("bad workflow definition: %v", e.Problems)
+}
+
+// LoadWorkflow reads a workflow definition file
+func LoadWorkflow(path string) (*Workflow, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseWorkflow(data)
+}
+
+// ParseWorkflow reads a workflow definition, in YAML or JSON. Unknown
+// keys are an error, so that a misspelt one isn't silently ignored.
+func ParseWorkflow(data []byte) (*Workflow, error) {
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var def WorkflowDefinition
+	if err := dec.Decode(&def); err != nil {
+		return nil, err
+	}
+
+	return def.Workflow()
+}
+
+// Workflow checks the definition, and builds the workflow from it
+func (def WorkflowDefinition) Workflow() (*Workflow, error) {
+
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	w := &Workflow{Name: def.Name}
+
+	if def.Name == "" {
+		problem("no name")
+	}
+
+	index := make(map[string]int)
+
+	for i, state := range def.States {
+		if state == "" {
+			problem("state %d has no name", i)
+			continue
+		}
+		if _, dup := index[state]; dup {
+			problem("state %s listed twice", state)
+			continue
+		}
+		index[state] = len(w.States)
+		w.States = append(w.States, state)
+	}
+
+	if len(w.States) == 0 {
+		problem("no states")
+	}
+
+	state := func(name, what string) int {
+		i, ok := index[name]
+		if !ok {
+			problem("%s: unknown state %q", what, name)
+		}
+		return i
+	}
+
+	w.Initial = state(def.Initial, "initial")
+
+	pairs := make(map[[2]int]bool)
+
+	for _, td := range def.Transitions {
+
+		what := "transition " + td.Name
+		if td.Name == "" {
+			problem("transition from %s to %s has no name", td.From, td.To)
+		}
+
+		t := Transition{
+			Name:     td.Name,
+			From:     state(td.From, what),
+			To:       state(td.To, what),
+			LoopBack: td.LoopBack,
+		}
+
+		if len(td.Roles) > 0 {
+			t.Roles = td.Roles
+		}
+
+		_, fromOK := index[td.From]
+		_, toOK := index[td.To]
+		if !fromOK || !toOK {
+			continue
+		}
+
+		switch {
+		case t.From == t.To:
+			problem("%s: goes nowhere", what)
+		case t.To < t.From && !t.LoopBack:
+			problem("%s: goes back from %s to %s, so must be a loopBack", what, td.From, td.To)
+		case t.To > t.From && t.LoopBack:
+			problem("%s: is a loopBack, but goes forward from %s to %s", what, td.From, td.To)
+		}
+
+		pair := [2]int{t.From, t.To}
+		if pairs[pair] {
+			problem("%s: more than one transition from %s to %s", what, td.From, td.To)
+		}
+		pairs[pair] = true
+
+		w.Transitions = append(w.Transitions, t)
+	}
+
+	if len(problems) > 0 {
+		return nil, &WorkflowDefinitionError{Problems: problems}
+	}
+
+	return w, nil
+}
+
+// Definition is the workflow in file form, e.g. to save DefaultWorkflow
+// as a starting point for another
+func (w *Workflow) Definition() WorkflowDefinition {
+
+	def := WorkflowDefinition{
+		Name:    w.Name,
+		Initial: w.StateName(w.Initial),
+		States:  append([]string(nil), w.States...),
+	}
+
+	for _, t := range w.Transitions {
+		def.Transitions = append(def.Transitions, TransitionDefinition{
+			Name:     t.Name,
+			From:     w.StateName(t.From),
+			To:       w.StateName(t.To),
+			Roles:    t.Roles,
+			LoopBack: t.LoopBack,
+		})
+	}
+
+	return def
+}
+
+// ValidateHistory checks that the transitions recorded on a page follow
+// the workflow: each starts where the last finished, is allowed, and was
+// made by a permitted role. Problems are at the path of the offending
+// processing step; a step named differently from its transition is only
+// noted, as info.
+func (w *Workflow) ValidateHistory(pd PageData) []Problem {
+
+	v := &validator{}
+
+	type recorded struct {
+		index    int
+		sequence int
+		step     ProcessingDetails
+	}
+
+	var steps []recorded
+
+	seqs := StepSequences(pd.Processing)
+
+	for i, step := range pd.Processing {
+		if _, ok := stepParameter(step, StateToParameter); ok {
+			steps = append(steps, recorded{i, seqs[i], step})
+		}
+	}
+
+	// oldest first, ordered the same way as SelectProcessByLast
+	useHLC := allStamped(len(steps), func(i int) *HLC { return steps[i].step.HLC })
+	sort.SliceStable(steps, func(i, j int) bool {
+		a, b := steps[i], steps[j]
+		return compareSteps(a.step, b.step, a.sequence, b.sequence, useHLC) < 0
+	})
+
+	current := w.Initial
+
+	for _, r := range steps {
+
+		path := fmt.Sprintf("/processing/%d", r.index)
+
+		toName, _ := stepParameter(r.step, StateToParameter)
+		to, err := w.ParseState(toName)
+		if err != nil {
+			v.add(SeverityError, path, "unknown state %q in workflow %s", toName, w.Name)
+			continue
+		}
+
+		if fromName, ok := stepParameter(r.step, StateFromParameter); ok && fromName != w.StateName(current) {
+			v.add(SeverityError, path, "starts from %s, but the page was %s", fromName, w.StateName(current))
+		}
+
+		t, ok := w.Allowed(current, to)
+		role, _ := stepParameter(r.step, RoleParameter)
+
+		switch {
+		case !ok:
+			v.add(SeverityError, path, "cannot go from %s to %s", w.StateName(current), toName)
+		case !t.permits(role):
+			v.add(SeverityError, path, "role %s cannot go from %s to %s", roleOrNone(role), w.StateName(current), toName)
+		case t.Name != r.step.Name:
+			v.add(SeverityInfo, path, "step is named %s, but the transition is %s", r.step.Name, t.Name)
+		}
+
+		current = to
+	}
+
+	return v.problems
+}
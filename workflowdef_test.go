@@ -0,0 +1,171 @@
+package pdfpagedata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadWorkflows(t *testing.T) {
+
+	for _, path := range []string{
+		"workflows/remark-after-check.yaml",
+		"workflows/double-blind.yaml",
+		"workflows/no-moderation.json",
+	} {
+		w, err := LoadWorkflow(path)
+		assert.NoError(t, err, path)
+		assert.NotEmpty(t, w.Transitions, path)
+	}
+
+	_, err := LoadWorkflow("workflows/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestRemarkAfterCheck(t *testing.T) {
+
+	w, err := LoadWorkflow("workflows/remark-after-check.yaml")
+	assert.NoError(t, err)
+
+	marker := ContactDetails{Name: "marker"}
+	checker := ContactDetails{Name: "checker"}
+
+	pd := PageData{}
+	assert.Equal(t, "raw", w.StateName(w.State(pd)))
+
+	steps := []struct {
+		to   string
+		role string
+		by   ContactDetails
+	}{
+		{"ready-to-mark", "", marker},
+		{"marked", "marker", marker},
+		{"ready-to-check", "", checker},
+		{"checked", "checker", checker},
+		{"ready-to-mark", "checker", checker},
+		{"marked", "marker", marker},
+	}
+
+	for _, step := range steps {
+		to, err := w.ParseState(step.to)
+		assert.NoError(t, err)
+		pd, err = w.TransitionAs(pd, to, step.role, step.by, nil)
+		assert.NoError(t, err, step.to)
+	}
+
+	assert.Equal(t, "marked", w.StateName(w.State(pd)))
+	assert.Equal(t, "checker", pd.Processing[4].Parameters[2].Value)
+	assert.Empty(t, w.ValidateHistory(pd))
+
+	// wrong role
+	checked, _ := w.ParseState("ready-to-check")
+	pd, err = w.TransitionAs(pd, checked, "", marker, nil)
+	assert.NoError(t, err)
+	done, _ := w.ParseState("checked")
+	_, err = w.TransitionAs(pd, done, "marker", marker, nil)
+	assert.EqualError(t, err, "role marker cannot go from ready-to-check to checked")
+	_, err = w.TransitionAs(pd, done, "", marker, nil)
+	assert.EqualError(t, err, "role (none) cannot go from ready-to-check to checked")
+}
+
+func TestValidateHistory(t *testing.T) {
+
+	w, err := LoadWorkflow("workflows/double-blind.yaml")
+	assert.NoError(t, err)
+
+	step := func(name, from, to, role string, seq int) ProcessingDetails {
+		params := []ParameterDetails{
+			ParameterDetails{Name: StateFromParameter, Value: from},
+			ParameterDetails{Name: StateToParameter, Value: to},
+		}
+		if role != "" {
+			params = append(params, ParameterDetails{Name: RoleParameter, Value: role})
+		}
+		return ProcessingDetails{Name: name, Sequence: seq, Parameters: params}
+	}
+
+	// out of order in the slice, but sequenced correctly
+	pd := PageData{Processing: []ProcessingDetails{
+		step("first-mark", "ready-to-mark", "first-marked", "first-marker", 2),
+		step("ready-to-mark", "raw", "ready-to-mark", "", 1),
+		ProcessingDetails{Name: "split", Sequence: 0},
+	}}
+	assert.Empty(t, w.ValidateHistory(pd))
+
+	// skipping the second marker, by the wrong role, and misnamed
+	pd.Processing = append(pd.Processing,
+		step("reconcile", "first-marked", "reconciled", "moderator", 3),
+		step("second-mark", "first-marked", "second-marked", "first-marker", 4),
+		step("oops", "reconciled", "nowhere", "", 5),
+	)
+
+	assert.Equal(t, []Problem{
+		Problem{SeverityError, "/processing/3", "cannot go from first-marked to reconciled"},
+		Problem{SeverityError, "/processing/4", "starts from first-marked, but the page was reconciled"},
+		Problem{SeverityError, "/processing/4", "cannot go from reconciled to second-marked"},
+		Problem{SeverityError, "/processing/5", `unknown state "nowhere" in workflow double-blind`},
+	}, w.ValidateHistory(pd))
+
+	pd.Processing = []ProcessingDetails{
+		step("ready-to-mark", "raw", "ready-to-mark", "", 1),
+		step("mark", "ready-to-mark", "first-marked", "second-marker", 2),
+	}
+	assert.Equal(t, []Problem{
+		Problem{SeverityError, "/processing/1", "role second-marker cannot go from ready-to-mark to first-marked"},
+	}, w.ValidateHistory(pd))
+
+	// allowed, but not named after its transition
+	pd.Processing[1] = step("mark", "ready-to-mark", "first-marked", "first-marker", 2)
+	problems := w.ValidateHistory(pd)
+	assert.Equal(t, []Problem{
+		Problem{SeverityInfo, "/processing/1", "step is named mark, but the transition is first-mark"},
+	}, problems)
+	assert.False(t, HasErrors(problems))
+}
+
+func TestBadWorkflowDefinitions(t *testing.T) {
+
+	_, err := ParseWorkflow([]byte(`
+name: bad
+initial: start
+states: [raw, marked, raw, ""]
+transitions:
+  - {name: mark, from: raw, to: marked}
+  - {name: again, from: raw, to: marked}
+  - {name: unmark, from: marked, to: raw}
+  - {name: forward, from: raw, to: marked, loopBack: true}
+  - {name: stay, from: raw, to: raw}
+  - {from: raw, to: checked}
+`))
+
+	if assert.IsType(t, &WorkflowDefinitionError{}, err) {
+		assert.Equal(t, []string{
+			"state raw listed twice",
+			"state 3 has no name",
+			`initial: unknown state "start"`,
+			"transition again: more than one transition from raw to marked",
+			"transition unmark: goes back from marked to raw, so must be a loopBack",
+			"transition forward: is a loopBack, but goes forward from raw to marked",
+			"transition forward: more than one transition from raw to marked",
+			"transition stay: goes nowhere",
+			"transition from raw to checked has no name",
+			`transition : unknown state "checked"`,
+		}, err.(*WorkflowDefinitionError).Problems)
+	}
+
+	// misspelt key
+	_, err = ParseWorkflow([]byte(`{"name": "x", "initial": "a", "states": ["a"], "transitons": []}`))
+	assert.Error(t, err)
+}
+
+func TestDefaultWorkflowDefinition(t *testing.T) {
+
+	data, err := yaml.Marshal(DefaultWorkflow.Definition())
+	assert.NoError(t, err)
+
+	w, err := ParseWorkflow(data)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultWorkflow.States, w.States)
+	assert.Equal(t, DefaultWorkflow.Transitions, w.Transitions)
+}